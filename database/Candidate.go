@@ -0,0 +1,58 @@
+package database
+
+import (
+	"os"
+)
+
+const candidateFileSuffix = ".candidate"
+
+// CandidateFilename returns the file holding the candidate datastore (RFC 6241 section 8.3)
+// of the running datastore held by filename.
+func CandidateFilename(filename string) string {
+	return filename + candidateFileSuffix
+}
+
+// LoadCandidate loads the candidate datastore of the running datastore held by filename.
+// Until it is edited, the candidate datastore is a copy of running.
+func LoadCandidate(filename string) (*Database, error) {
+	candidate, err := Load(CandidateFilename(filename))
+	if err == nil {
+		return candidate, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	running, err := Load(filename)
+	if os.IsNotExist(err) {
+		return NewDatabase(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return running.Copy()
+}
+
+// Commit replaces the content of the running datastore with the one of the candidate datastore.
+// The resources keep the metadata they were given when edited in the candidate datastore.
+func (db *Database) Commit(candidate *Database) error {
+	committed, err := candidate.Copy()
+	if err != nil {
+		return err
+	}
+	db.m.Lock()
+	defer db.m.Unlock()
+	db.Content = committed.Content
+	db.metadata = committed.metadata
+	return nil
+}
+
+// DiscardChanges reverts the candidate datastore of the running datastore held by filename to the content of running.
+func DiscardChanges(filename string) error {
+	for _, candidateFile := range []string{CandidateFilename(filename), CandidateFilename(filename) + metadataFileSuffix} {
+		err := os.Remove(candidateFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
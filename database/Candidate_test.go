@@ -0,0 +1,58 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCandidate_NoCandidateFile_CopyOfRunning(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "database.json")
+	running := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"a":{"x":1}}`)))}
+	_ = running.Modified()
+	_ = running.Save(filename)
+
+	candidate, err := LoadCandidate(filename)
+
+	assert.NoError(t, err)
+	content, _ := ajson.Marshal(candidate.Content)
+	assert.Equal(t, `{"a":{"x":1}}`, string(content))
+}
+
+func TestDatabase_Commit_EditedCandidate_RunningReplaced(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "database.json")
+	running := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"a":{"x":1}}`)))}
+	_ = running.Modified()
+	_ = running.Save(filename)
+	candidate, _ := LoadCandidate(filename)
+	_, _ = candidate.Put(`$["a"]["x"]`, ajson.NumericNode("", 2), Insertion{})
+	_ = candidate.Save(CandidateFilename(filename))
+	edited, _ := candidate.Metadata(`$["a"]`)
+
+	err := running.Commit(candidate)
+
+	assert.NoError(t, err)
+	content, _ := ajson.Marshal(running.Content)
+	assert.Equal(t, `{"a":{"x":2}}`, string(content))
+	committed, _ := running.Metadata(`$["a"]`)
+	assert.Equal(t, edited, committed)
+}
+
+func TestDiscardChanges_EditedCandidate_CandidateRevertedToRunning(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "database.json")
+	running := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"a":{"x":1}}`)))}
+	_ = running.Modified()
+	_ = running.Save(filename)
+	candidate, _ := LoadCandidate(filename)
+	_, _ = candidate.Put(`$["a"]["x"]`, ajson.NumericNode("", 2), Insertion{})
+	_ = candidate.Save(CandidateFilename(filename))
+
+	err := DiscardChanges(filename)
+
+	assert.NoError(t, err)
+	candidate, _ = LoadCandidate(filename)
+	content, _ := ajson.Marshal(candidate.Content)
+	assert.Equal(t, `{"a":{"x":1}}`, string(content))
+}
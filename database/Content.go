@@ -0,0 +1,142 @@
+package database
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+	"strings"
+)
+
+const (
+	ContentAll       = "all"
+	ContentConfig    = "config"
+	ContentNonconfig = "nonconfig"
+)
+
+// FilterContent keeps only the configuration or only the non-configuration descendants of node,
+// as requested by the RESTCONF "content" query parameter. Ancestors and list keys of the kept nodes are retained.
+func FilterContent(node *ajson.Node, schema *openapi3.Schema, content string) error {
+	if content == ContentAll {
+		return nil
+	}
+	_, err := filterContent(node, schema, IsConfig(schema, true), content == ContentConfig)
+	return err
+}
+
+func filterContent(node *ajson.Node, schema *openapi3.Schema, config bool, wantConfig bool) (keep bool, err error) {
+	if !config {
+		return !wantConfig, nil
+	}
+	switch node.Type() {
+	case ajson.Array:
+		for i := node.Size() - 1; i >= 0; i-- {
+			element, err := node.GetIndex(i)
+			if err != nil {
+				return false, err
+			}
+			keepElement, err := filterObjectContent(element, schema, config, wantConfig)
+			if err != nil {
+				return false, err
+			}
+			if !keepElement {
+				err = node.DeleteIndex(i)
+				if err != nil {
+					return false, err
+				}
+			}
+		}
+		return wantConfig || node.Size() > 0, nil
+	case ajson.Object:
+		return filterObjectContent(node, schema, config, wantConfig)
+	default:
+		return wantConfig, nil
+	}
+}
+
+func filterObjectContent(node *ajson.Node, schema *openapi3.Schema, config bool, wantConfig bool) (keep bool, err error) {
+	if !node.IsObject() {
+		return wantConfig, nil
+	}
+	keys := ListKeys(schema)
+	isKey := func(key string) bool {
+		for _, listKey := range keys {
+			if listKey == key {
+				return true
+			}
+		}
+		return false
+	}
+	keptOthers := false
+	for _, key := range node.Keys() {
+		if isKey(key) || strings.HasPrefix(key, "@") {
+			continue
+		}
+		child, err := node.GetKey(key)
+		if err != nil {
+			return false, err
+		}
+		childSchema := ChildSchema(schema, key)
+		keepChild, err := filterContent(child, childSchema, IsConfig(childSchema, config), wantConfig)
+		if err != nil {
+			return false, err
+		}
+		if !keepChild {
+			err = node.DeleteKey(key)
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+		keptOthers = true
+	}
+	for _, key := range node.Keys() {
+		if strings.HasPrefix(key, "@") && !node.HasKey(key[1:]) {
+			err = node.DeleteKey(key)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	if wantConfig || keptOthers {
+		return true, nil
+	}
+	for _, key := range keys {
+		if node.HasKey(key) {
+			err = node.DeleteKey(key)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// FindNonconfig returns the path of the first non-configuration node found in node, which is described by schema.
+func FindNonconfig(node *ajson.Node, schema *openapi3.Schema, config bool) (path string, found bool) {
+	config = IsConfig(schema, config)
+	if !config {
+		return "", true
+	}
+	switch node.Type() {
+	case ajson.Array:
+		for i := 0; i < node.Size(); i++ {
+			element, err := node.GetIndex(i)
+			if err != nil {
+				continue
+			}
+			if path, found = FindNonconfig(element, ItemSchema(schema), config); found {
+				return path, true
+			}
+		}
+	case ajson.Object:
+		for _, key := range node.Keys() {
+			child, err := node.GetKey(key)
+			if err != nil {
+				continue
+			}
+			if path, found = FindNonconfig(child, ChildSchema(schema, key), config); found {
+				return "/" + key + path, true
+			}
+		}
+	}
+	return "", false
+}
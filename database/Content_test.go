@@ -0,0 +1,92 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+const interfacesSchema = `{
+	"type": "object",
+	"properties": {
+		"interface": {
+			"type": "array",
+			"x-key": "name",
+			"items": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"mtu": {"type": "integer"},
+					"statistics": {
+						"type": "object",
+						"x-config": false,
+						"properties": {"in-octets": {"type": "integer"}}
+					},
+					"oper-status": {"type": "string", "readOnly": true}
+				}
+			}
+		}
+	}
+}`
+
+const interfacesData = `{"interface":[
+	{"name":"eth0","mtu":1500,"statistics":{"in-octets":10},"oper-status":"up"},
+	{"name":"eth1","mtu":9000}
+]}`
+
+func TestFilterContent_GivenContent_MatchingNodesKept(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{
+			ContentAll,
+			interfacesData,
+		},
+		{
+			ContentConfig,
+			`{"interface":[{"name":"eth0","mtu":1500},{"name":"eth1","mtu":9000}]}`,
+		},
+		{
+			ContentNonconfig,
+			`{"interface":[{"name":"eth0","statistics":{"in-octets":10},"oper-status":"up"}]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.content, func(t *testing.T) {
+			var schema openapi3.Schema
+			_ = json.Unmarshal([]byte(interfacesSchema), &schema)
+			node := ajson.Must(ajson.Unmarshal([]byte(interfacesData)))
+
+			err := FilterContent(node, &schema, test.content)
+
+			assert.NoError(t, err)
+			actual, _ := ajson.Marshal(node)
+			assert.JSONEq(t, test.expected, string(actual))
+		})
+	}
+}
+
+func TestFindNonconfig_DataWithStateNode_PathOfStateNode(t *testing.T) {
+	var schema openapi3.Schema
+	_ = json.Unmarshal([]byte(interfacesSchema), &schema)
+	node := ajson.Must(ajson.Unmarshal([]byte(`{"interface":[{"name":"eth0","oper-status":"up"}]}`)))
+
+	path, found := FindNonconfig(node, &schema, true)
+
+	assert.True(t, found)
+	assert.Equal(t, "/interface/oper-status", path)
+}
+
+func TestFindNonconfig_ConfigData_NotFound(t *testing.T) {
+	var schema openapi3.Schema
+	_ = json.Unmarshal([]byte(interfacesSchema), &schema)
+	node := ajson.Must(ajson.Unmarshal([]byte(`{"interface":[{"name":"eth0","mtu":1500}]}`)))
+
+	_, found := FindNonconfig(node, &schema, true)
+
+	assert.False(t, found)
+}
@@ -3,7 +3,6 @@ package database
 import (
 	"encoding/json"
 	"github.com/exgphe/kin-openapi/openapi3"
-	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 	"github.com/spyzhov/ajson"
 	"io/fs"
@@ -13,7 +12,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"time"
 )
 
 type Key = string
@@ -25,13 +23,11 @@ type KeyPath = string
 type Dictionary = *ajson.Node
 
 type Database struct {
-	Content Dictionary
-	m       sync.Mutex
+	Content  Dictionary
+	metadata map[string]recordedMetadata
+	m        sync.Mutex
 }
 
-const lastModifiedKey = "@@last-modified"
-const eTagKey = "@@etag"
-
 type KeyPathNotFoundError struct {
 }
 
@@ -58,6 +54,20 @@ func NewDatabase() (db *Database) {
 	return
 }
 
+// Copy returns an independent copy of the datastore, sharing the metadata of its resources.
+func (db *Database) Copy() (*Database, error) {
+	db.m.Lock()
+	defer db.m.Unlock()
+	content, err := cloneNode(db.Content)
+	if err != nil {
+		return nil, err
+	}
+	return &Database{
+		Content:  content,
+		metadata: copyMetadata(db.metadata),
+	}, nil
+}
+
 func Load(filename string) (db *Database, err error) {
 	_, err = os.Stat(filename)
 	if !os.IsNotExist(err) {
@@ -71,6 +81,20 @@ func Load(filename string) (db *Database, err error) {
 			Content: nil,
 		}
 		db.Content, err = ajson.Unmarshal(fileContent)
+		if err != nil {
+			return
+		}
+		db.metadata, err = loadMetadata(filename)
+		if err != nil {
+			return
+		}
+		err = db.migrateLegacyMetadata()
+		if err != nil {
+			return
+		}
+		if _, recorded := db.metadata[""]; !recorded {
+			err = db.modified("$")
+		}
 	}
 	return
 }
@@ -133,41 +157,17 @@ func (db *Database) Save(filename string) (err error) {
 		return
 	}
 	err = ioutil.WriteFile(filename, fileData, fs.ModePerm)
-	return
-}
-
-func (db *Database) Modified() error {
-	//db.m.Lock()
-	//defer db.m.Unlock()
-	err := db.Content.AppendObject(lastModifiedKey, ajson.StringNode(lastModifiedKey, time.Now().Format(time.RFC1123)))
-	if err != nil {
-		return err
-	}
-	v4, err := uuid.NewV4()
-	if err != nil {
-		return err
-	}
-	err = db.Content.AppendObject(eTagKey, ajson.StringNode(eTagKey, "\""+v4.String()+"\""))
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func (db *Database) GetLastModified() (lastModified string, err error) {
-	lastModifiedNode, err := db.Content.GetKey(lastModifiedKey)
 	if err != nil {
 		return
 	}
-	return lastModifiedNode.GetString()
+	return saveMetadata(filename, db.metadata)
 }
 
-func (db *Database) GetETag() (eTag string, err error) {
-	eTagNode, err := db.Content.GetKey(eTagKey)
-	if err != nil {
-		return
-	}
-	return eTagNode.GetString()
+// Modified marks the whole datastore as modified.
+func (db *Database) Modified() error {
+	db.m.Lock()
+	defer db.m.Unlock()
+	return db.modified("$")
 }
 
 func (db *Database) Get(keyPath KeyPath) (value Value, parentIsArray bool, err error) {
@@ -192,6 +192,10 @@ func (db *Database) Get(keyPath KeyPath) (value Value, parentIsArray bool, err e
 func (db *Database) EnsureKeyPath(keyPath KeyPath) (err error) {
 	db.m.Lock()
 	defer db.m.Unlock()
+	return db.ensureKeyPath(keyPath)
+}
+
+func (db *Database) ensureKeyPath(keyPath KeyPath) (err error) {
 	paths, err := ajson.ParseJSONPath(keyPath)
 	if err != nil {
 		return
@@ -287,7 +291,7 @@ func (db *Database) Delete(keyPath KeyPath) (err error) {
 	if err != nil {
 		return err
 	}
-	err = db.Modified()
+	err = db.modified(keyPath)
 	if err != nil {
 		return err
 	}
@@ -418,7 +422,8 @@ func (db *Database) Delete(keyPath KeyPath) (err error) {
 //	return
 //}
 
-func (db *Database) Put(keyPath string, node *ajson.Node) (created bool, err error) {
+// Put replaces the node at keyPath, creating it if needed. A list entry is placed or moved according to insertion.
+func (db *Database) Put(keyPath string, node *ajson.Node, insertion Insertion) (created bool, err error) {
 	db.m.Lock()
 	defer db.m.Unlock()
 	nodes, err := db.Content.JSONPath(keyPath)
@@ -428,7 +433,10 @@ func (db *Database) Put(keyPath string, node *ajson.Node) (created bool, err err
 	if len(nodes) == 0 {
 		created = true
 	}
-	err = db.EnsureKeyPath(keyPath)
+	err = db.ensureKeyPath(keyPath)
+	if err != nil {
+		return
+	}
 	nodes, err = db.Content.JSONPath(keyPath)
 	if err != nil {
 		return
@@ -453,8 +461,11 @@ func (db *Database) Put(keyPath string, node *ajson.Node) (created bool, err err
 			if err != nil {
 				return false, err
 			}
-			err = nodes[0].AppendArray(nodeElements[0])
-			return true, err
+			err = insertEntry(nodes[0], nodeElements[0], insertion)
+			if err != nil {
+				return false, err
+			}
+			return true, db.modified(keyPath)
 		} else {
 			return false, &KeyPathEmptyError{}
 		}
@@ -479,10 +490,20 @@ func (db *Database) Put(keyPath string, node *ajson.Node) (created bool, err err
 		if err != nil {
 			return false, err
 		}
-		err = targetNode.SetObject(valueObject)
+		err = setObject(targetNode, valueObject)
 		if err != nil {
 			return false, err
 		}
+		if parent := targetNode.Parent(); parent != nil && parent.IsArray() && insertion.Where != "" {
+			err = targetNode.Delete()
+			if err != nil {
+				return false, err
+			}
+			err = insertEntry(parent, targetNode, insertion)
+			if err != nil {
+				return false, err
+			}
+		}
 	case ajson.Bool:
 		value, err := node.GetBool()
 		if err != nil {
@@ -515,24 +536,25 @@ func (db *Database) Put(keyPath string, node *ajson.Node) (created bool, err err
 		if err != nil {
 			return false, err
 		}
-		err = targetNode.SetObject(value)
+		err = setObject(targetNode, value)
 		if err != nil {
 			return false, err
 		}
 	default:
 		return false, errors.New("Should not happen")
 	}
-	err = db.Modified()
+	err = db.modified(keyPath)
 	if err != nil {
 		return false, err
 	}
 	return
 }
 
-func (db *Database) Post(keyPath string, node *ajson.Node, key string, listKeys []string) (appendKey string, err error) {
+// Post creates the child key of the node at keyPath. A new list entry is placed according to insertion.
+func (db *Database) Post(keyPath string, node *ajson.Node, key string, listKeys []string, insertion Insertion) (appendKey string, err error) {
 	db.m.Lock()
 	defer db.m.Unlock()
-	err = db.EnsureKeyPath(keyPath)
+	err = db.ensureKeyPath(keyPath)
 	if err != nil {
 		return
 	}
@@ -545,6 +567,7 @@ func (db *Database) Post(keyPath string, node *ajson.Node, key string, listKeys
 	}
 	parentNode := parentNodes[0]
 	currentKeyPath := keyPath + "[\"" + key + "\"]"
+	createdKeyPath := currentKeyPath
 	currentNodes, err := db.Content.JSONPath(currentKeyPath)
 	if err != nil {
 		return "", err
@@ -561,7 +584,7 @@ func (db *Database) Post(keyPath string, node *ajson.Node, key string, listKeys
 			return "", errors.New("Cannot Create Multiple List Items at One Time")
 		}
 		element := nodeArray[0]
-		err = db.EnsureKeyPath(currentKeyPath)
+		err = db.ensureKeyPath(currentKeyPath)
 		if err != nil {
 			return "", err
 		}
@@ -572,6 +595,10 @@ func (db *Database) Post(keyPath string, node *ajson.Node, key string, listKeys
 			if err != nil {
 				return "", err
 			}
+			if i != 0 {
+				appendKey += ","
+				currentArrayElementKeyPath += "&&"
+			}
 			var valueString string
 			switch value.Type() {
 			case ajson.String:
@@ -592,13 +619,10 @@ func (db *Database) Post(keyPath string, node *ajson.Node, key string, listKeys
 			default:
 				return "", errors.New("Complex Key Type Currently Not Supported")
 			}
-			if i != 0 {
-				appendKey += ","
-				currentArrayElementKeyPath += "&&"
-			}
 			appendKey += url.PathEscape(valueString)
 		}
 		currentArrayElementKeyPath += ")]"
+		createdKeyPath = currentArrayElementKeyPath
 		currentArrayElementNodes, err := db.Content.JSONPath(currentArrayElementKeyPath)
 		if err != nil {
 			return "", err
@@ -616,7 +640,7 @@ func (db *Database) Post(keyPath string, node *ajson.Node, key string, listKeys
 				return "", err
 			}
 		}
-		err = currentNode.AppendArray(element)
+		err = insertEntry(currentNode, element, insertion)
 		if err != nil {
 			return "", err
 		}
@@ -633,14 +657,15 @@ func (db *Database) Post(keyPath string, node *ajson.Node, key string, listKeys
 			return "", err
 		}
 	}
-	err = db.Modified()
+	err = db.modified(createdKeyPath)
 	if err != nil {
 		return
 	}
 	return
 }
 
-func (db *Database) Patch(keyPath string, patchNode *ajson.Node) (err error) {
+// Patch merges patchNode into the node at keyPath, which is described by schema.
+func (db *Database) Patch(keyPath string, patchNode *ajson.Node, schema *openapi3.Schema) (err error) {
 	db.m.Lock()
 	defer db.m.Unlock()
 	parentNodes, err := db.Content.JSONPath(keyPath)
@@ -654,23 +679,18 @@ func (db *Database) Patch(keyPath string, patchNode *ajson.Node) (err error) {
 		return &KeyPathNotUniqueError{}
 	}
 	parentNode := parentNodes[0]
-	var object map[string]*ajson.Node
-	if patchNode.IsArray() {
-		array, _ := patchNode.GetArray()
-		object, err = array[0].GetObject()
-	} else {
-		object, err = patchNode.GetObject()
-	}
-	if err != nil {
-		return err
-	}
-	for key, node := range object {
-		err := parentNode.AppendObject(key, node)
+	source := patchNode
+	if patchNode.IsArray() && !parentNode.IsArray() {
+		source, err = patchNode.GetIndex(0)
 		if err != nil {
 			return err
 		}
 	}
-	err = db.Modified()
+	err = MergeNode(parentNode, source, schema)
+	if err != nil {
+		return err
+	}
+	err = db.modified(keyPath)
 	if err != nil {
 		return err
 	}
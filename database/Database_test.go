@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
@@ -25,3 +26,16 @@ func TestAJson(t *testing.T) {
 	}
 	fmt.Printf("%s", marshalled)
 }
+
+func TestDatabase_Post_CompositeKey_EntryAppendedOnce(t *testing.T) {
+	db := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"routing":{"route":[{"prefix":"a","metric":1}]}}`)))}
+	entry := ajson.Must(ajson.Unmarshal([]byte(`[{"prefix":"a","metric":2}]`)))
+	existing := ajson.Must(ajson.Unmarshal([]byte(`[{"prefix":"a","metric":1}]`)))
+
+	appendKey, err := db.Post(`$["routing"]`, entry, "route", []string{"prefix", "metric"}, Insertion{})
+	_, existingErr := db.Post(`$["routing"]`, existing, "route", []string{"prefix", "metric"}, Insertion{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "route=a,2", appendKey)
+	assert.IsType(t, &DataExistsError{}, existingErr)
+}
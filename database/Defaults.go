@@ -0,0 +1,180 @@
+package database
+
+import (
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+	"reflect"
+)
+
+const (
+	WithDefaultsExplicit        = "explicit"
+	WithDefaultsReportAll       = "report-all"
+	WithDefaultsReportAllTagged = "report-all-tagged"
+	WithDefaultsTrim            = "trim"
+)
+
+const defaultAnnotation = "ietf-netconf-with-defaults:default"
+
+// ApplyWithDefaults materializes or strips the schema default values of the descendants of node,
+// as requested by the RESTCONF "with-defaults" query parameter. The datastore only holds explicitly written values,
+// so the explicit mode leaves node untouched.
+func ApplyWithDefaults(node *ajson.Node, schema *openapi3.Schema, mode string) error {
+	if mode == WithDefaultsExplicit || schema == nil {
+		return nil
+	}
+	return applyWithDefaults(node, schema, mode, map[*openapi3.Schema]bool{})
+}
+
+func applyWithDefaults(node *ajson.Node, schema *openapi3.Schema, mode string, visiting map[*openapi3.Schema]bool) error {
+	if visiting[schema] {
+		return nil
+	}
+	visiting[schema] = true
+	defer delete(visiting, schema)
+
+	switch node.Type() {
+	case ajson.Array:
+		itemSchema := ItemSchema(schema)
+		for i := 0; i < node.Size(); i++ {
+			element, err := node.GetIndex(i)
+			if err != nil {
+				return err
+			}
+			if element.IsObject() {
+				err = applyWithDefaults(element, itemSchema, mode, visiting)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case ajson.Object:
+		for _, key := range node.Keys() {
+			childSchema := ChildSchema(schema, key)
+			if childSchema == nil {
+				continue
+			}
+			child, err := node.GetKey(key)
+			if err != nil {
+				return err
+			}
+			if !child.IsObject() && !child.IsArray() {
+				if childSchema.Default == nil || !equalsDefault(child, childSchema.Default) {
+					continue
+				}
+				switch mode {
+				case WithDefaultsTrim:
+					err = node.DeleteKey(key)
+				case WithDefaultsReportAllTagged:
+					err = tagDefault(node, key)
+				}
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			err = applyWithDefaults(child, childSchema, mode, visiting)
+			if err != nil {
+				return err
+			}
+		}
+		if mode == WithDefaultsTrim {
+			return nil
+		}
+		return materializeDefaults(node, schema, mode, visiting)
+	}
+	return nil
+}
+
+// materializeDefaults adds the missing leaves having a default value, and the missing containers holding some.
+func materializeDefaults(node *ajson.Node, schema *openapi3.Schema, mode string, visiting map[*openapi3.Schema]bool) error {
+	for name, property := range objectProperties(schema) {
+		if node.HasKey(name) || property.Value == nil || visiting[property.Value] {
+			continue
+		}
+		propertySchema := property.Value
+		if propertySchema.Default != nil {
+			child, err := defaultNode(propertySchema.Default)
+			if err != nil {
+				return err
+			}
+			err = node.AppendObject(name, child)
+			if err != nil {
+				return err
+			}
+			if mode == WithDefaultsReportAllTagged {
+				err = tagDefault(node, name)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if propertySchema.Type != "object" || propertySchema.Items != nil {
+			continue
+		}
+		container := ajson.ObjectNode(name, map[string]*ajson.Node{})
+		visiting[propertySchema] = true
+		err := materializeDefaults(container, propertySchema, mode, visiting)
+		delete(visiting, propertySchema)
+		if err != nil {
+			return err
+		}
+		if !container.Empty() {
+			err = node.AppendObject(name, container)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// objectProperties collects the properties of an object schema including the ones of combined schemas.
+func objectProperties(schema *openapi3.Schema) openapi3.Schemas {
+	properties := openapi3.Schemas{}
+	if schema == nil {
+		return properties
+	}
+	for _, ref := range schema.AllOf {
+		for name, property := range objectProperties(ref.Value) {
+			properties[name] = property
+		}
+	}
+	for name, property := range schema.Properties {
+		properties[name] = property
+	}
+	return properties
+}
+
+// tagDefault annotates the leaf key of node with the "ietf-netconf-with-defaults:default" metadata (RFC 8040 section 4.8.9).
+func tagDefault(node *ajson.Node, key string) error {
+	annotation := ajson.ObjectNode("@"+key, map[string]*ajson.Node{
+		defaultAnnotation: ajson.BoolNode(defaultAnnotation, true),
+	})
+	return node.AppendObject("@"+key, annotation)
+}
+
+func defaultNode(value interface{}) (*ajson.Node, error) {
+	marshal, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return ajson.Unmarshal(marshal)
+}
+
+func equalsDefault(node *ajson.Node, defaultValue interface{}) bool {
+	value, err := node.Unpack()
+	if err != nil {
+		return false
+	}
+	marshal, err := json.Marshal(defaultValue)
+	if err != nil {
+		return false
+	}
+	var normalized interface{}
+	if json.Unmarshal(marshal, &normalized) != nil {
+		return false
+	}
+	return reflect.DeepEqual(value, normalized)
+}
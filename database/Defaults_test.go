@@ -0,0 +1,65 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+const playerSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"gap": {"type": "number", "default": 0.5},
+		"volume": {"type": "integer", "default": 10},
+		"settings": {
+			"type": "object",
+			"properties": {"shuffle": {"type": "boolean", "default": false}}
+		}
+	}
+}`
+
+func TestApplyWithDefaults_GivenMode_DefaultsMaterializedOrStripped(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{
+			WithDefaultsExplicit,
+			`{"name":"p","gap":0.5}`,
+		},
+		{
+			WithDefaultsReportAll,
+			`{"name":"p","gap":0.5,"volume":10,"settings":{"shuffle":false}}`,
+		},
+		{
+			WithDefaultsTrim,
+			`{"name":"p"}`,
+		},
+		{
+			WithDefaultsReportAllTagged,
+			`{
+				"name":"p",
+				"gap":0.5,"@gap":{"ietf-netconf-with-defaults:default":true},
+				"volume":10,"@volume":{"ietf-netconf-with-defaults:default":true},
+				"settings":{"shuffle":false,"@shuffle":{"ietf-netconf-with-defaults:default":true}}
+			}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			var schema openapi3.Schema
+			_ = json.Unmarshal([]byte(playerSchema), &schema)
+			node := ajson.Must(ajson.Unmarshal([]byte(`{"name":"p","gap":0.5}`)))
+
+			err := ApplyWithDefaults(node, &schema, test.mode)
+
+			assert.NoError(t, err)
+			actual, _ := ajson.Marshal(node)
+			assert.JSONEq(t, test.expected, string(actual))
+		})
+	}
+}
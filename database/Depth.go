@@ -0,0 +1,44 @@
+package database
+
+import "github.com/spyzhov/ajson"
+
+// PruneDepth removes every descendant of node deeper than depth, node itself being at depth 1.
+// Entries of a list or leaf-list share the depth of the list they belong to.
+func PruneDepth(node *ajson.Node, depth int) error {
+	return pruneDepth(node, 1, depth)
+}
+
+func pruneDepth(node *ajson.Node, level int, depth int) error {
+	switch node.Type() {
+	case ajson.Array:
+		for i := 0; i < node.Size(); i++ {
+			element, err := node.GetIndex(i)
+			if err != nil {
+				return err
+			}
+			err = pruneDepth(element, level, depth)
+			if err != nil {
+				return err
+			}
+		}
+	case ajson.Object:
+		for _, key := range node.Keys() {
+			if level >= depth {
+				err := node.DeleteKey(key)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			child, err := node.GetKey(key)
+			if err != nil {
+				return err
+			}
+			err = pruneDepth(child, level+1, depth)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
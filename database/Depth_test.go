@@ -0,0 +1,52 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneDepth_GivenDepth_DescendantsBelowDepthRemoved(t *testing.T) {
+	tests := []struct {
+		name     string
+		depth    int
+		expected string
+	}{
+		{
+			"target only",
+			1,
+			`{}`,
+		},
+		{
+			"immediate children",
+			2,
+			`{"library":{},"playlist":[{},{}],"gap":0.5}`,
+		},
+		{
+			"list entries share list depth",
+			3,
+			`{"library":{"artist":[{}]},"playlist":[{"name":"A"},{"name":"B"}],"gap":0.5}`,
+		},
+		{
+			"deeper than data",
+			10,
+			`{"library":{"artist":[{"name":"X","album":[{"name":"Y"}]}]},"playlist":[{"name":"A"},{"name":"B"}],"gap":0.5}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := ajson.Must(ajson.Unmarshal([]byte(`{
+				"library":{"artist":[{"name":"X","album":[{"name":"Y"}]}]},
+				"playlist":[{"name":"A"},{"name":"B"}],
+				"gap":0.5
+			}`)))
+
+			err := PruneDepth(node, test.depth)
+
+			assert.NoError(t, err)
+			actual, _ := ajson.Marshal(node)
+			assert.JSONEq(t, test.expected, string(actual))
+		})
+	}
+}
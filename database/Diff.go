@@ -0,0 +1,376 @@
+package database
+
+import (
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Change is a difference between two versions of a datastore, in the form of a YANG Patch edit.
+type Change struct {
+	// Operation is one of EditCreate, EditDelete and EditReplace
+	Operation string
+	// Target is the RESTCONF data resource path of the changed node relative to the datastore root,
+	// such as "/ietf-network:networks/network=n1/node=a"
+	Target string
+	// Value holds the new content of the target node under its module-qualified name, as the value of a YANG Patch edit.
+	// It is a single entry array for list entries, and nil for deletions.
+	Value *ajson.Node
+}
+
+// savedChange is the JSON encoding of a Change.
+type savedChange struct {
+	Operation string
+	Target    string
+	Value     json.RawMessage `json:",omitempty"`
+}
+
+func (change Change) MarshalJSON() ([]byte, error) {
+	saved := savedChange{Operation: change.Operation, Target: change.Target}
+	if change.Value != nil {
+		value, err := ajson.Marshal(change.Value)
+		if err != nil {
+			return nil, err
+		}
+		saved.Value = value
+	}
+	return json.Marshal(saved)
+}
+
+func (change *Change) UnmarshalJSON(data []byte) error {
+	var saved savedChange
+	err := json.Unmarshal(data, &saved)
+	if err != nil {
+		return err
+	}
+	change.Operation = saved.Operation
+	change.Target = saved.Target
+	change.Value = nil
+	if len(saved.Value) > 0 && string(saved.Value) != "null" {
+		change.Value, err = ajson.Unmarshal(saved.Value)
+	}
+	return err
+}
+
+// Content returns the datastore content holding the value of the change at its target, with the ancestors
+// of the target and the key leaves of the ancestor list entries, so that filters can be applied to it.
+// The target of a deletion holds null, or the key leaves of the deleted list entry.
+func (change Change) Content(schema *openapi3.Schema) (*ajson.Node, error) {
+	var value *ajson.Node
+	if change.Value != nil && change.Value.IsObject() && change.Value.Size() == 1 {
+		var err error
+		value, err = cloneNode(change.Value.MustKey(change.Value.Keys()[0]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	content := ajson.ObjectNode("", map[string]*ajson.Node{})
+	node := content
+	segments := strings.Split(strings.TrimPrefix(change.Target, "/"), "/")
+	for i, segment := range segments {
+		name, keyValues, isEntry := strings.Cut(segment, "=")
+		schema = ChildSchema(schema, name)
+		last := i == len(segments)-1
+		var child *ajson.Node
+		switch {
+		case last && value != nil:
+			// the value of a list entry is already the single entry array
+			child = value
+		case isEntry:
+			entry := ajson.ObjectNode("", map[string]*ajson.Node{})
+			err := appendKeyValues(entry, keyValues, ListKeys(schema))
+			if err != nil {
+				return nil, err
+			}
+			err = node.AppendObject(name, ajson.ArrayNode("", []*ajson.Node{entry}))
+			if err != nil {
+				return nil, err
+			}
+			node = entry
+			schema = ItemSchema(schema)
+			continue
+		case last:
+			child = ajson.NullNode("")
+		default:
+			child = ajson.ObjectNode("", map[string]*ajson.Node{})
+		}
+		err := node.AppendObject(name, child)
+		if err != nil {
+			return nil, err
+		}
+		node = child
+	}
+	return content, nil
+}
+
+// appendKeyValues sets the key leaves of a list entry to the escaped values of a target, such as "a,b%2Fc".
+func appendKeyValues(entry *ajson.Node, keyValues string, listKeys []string) error {
+	values := strings.Split(keyValues, ",")
+	for i, listKey := range listKeys {
+		if i >= len(values) {
+			break
+		}
+		value, err := url.PathUnescape(values[i])
+		if err != nil {
+			return err
+		}
+		err = entry.AppendObject(listKey, ajson.StringNode("", value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Diff compares two versions of a datastore content and returns the changes turning previous into after.
+// List entries are matched by the key leaves declared by the "x-key" extension of the list schema.
+// A list entry whose own content changed is replaced as a whole, while the entries of its nested lists are compared
+// on their own. Outside of lists, the smallest changed nodes are reported.
+func Diff(previous *ajson.Node, after *ajson.Node, schema *openapi3.Schema) ([]Change, error) {
+	differ := &differ{}
+	err := differ.diffMembers("", "", previous, after, schema)
+	return differ.changes, err
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (differ *differ) add(operation string, target string, name string, value *ajson.Node) error {
+	change := Change{Operation: operation, Target: target}
+	if value != nil {
+		clone, err := cloneNode(value)
+		if err != nil {
+			return err
+		}
+		change.Value = ajson.ObjectNode("", map[string]*ajson.Node{})
+		err = change.Value.AppendObject(name, clone)
+		if err != nil {
+			return err
+		}
+	}
+	differ.changes = append(differ.changes, change)
+	return nil
+}
+
+// diffMembers compares the members of two versions of a container, either of which may be missing.
+func (differ *differ) diffMembers(target string, module string, previous *ajson.Node, after *ajson.Node, schema *openapi3.Schema) error {
+	for _, name := range memberNames(previous, after) {
+		previousChild, afterChild := member(previous, name), member(after, name)
+		childModule := moduleName(name, module)
+		childTarget := target + "/" + name
+		childSchema := ChildSchema(schema, name)
+		var err error
+		switch {
+		case isKeyedList(previousChild, afterChild, childSchema):
+			err = differ.diffEntries(childTarget, qualifiedName(name, module), childModule, previousChild, afterChild, childSchema)
+		case previousChild == nil:
+			err = differ.add(EditCreate, childTarget, qualifiedName(name, module), afterChild)
+		case afterChild == nil:
+			err = differ.add(EditDelete, childTarget, "", nil)
+		case previousChild.IsObject() && afterChild.IsObject():
+			err = differ.diffMembers(childTarget, childModule, previousChild, afterChild, childSchema)
+		default:
+			var equal bool
+			equal, err = previousChild.Eq(afterChild)
+			if err == nil && !equal {
+				err = differ.add(EditReplace, childTarget, qualifiedName(name, module), afterChild)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffEntries compares two versions of a keyed list, either of which may be missing.
+func (differ *differ) diffEntries(target string, name string, module string, previous *ajson.Node, after *ajson.Node, schema *openapi3.Schema) error {
+	listKeys := ListKeys(schema)
+	for _, previousEntry := range entries(previous) {
+		entryTarget, ok := listEntryTarget(target, previousEntry, listKeys)
+		if !ok {
+			continue
+		}
+		var err error
+		afterEntry := findListEntry(after, previousEntry, listKeys)
+		if afterEntry == nil {
+			err = differ.add(EditDelete, entryTarget, "", nil)
+		} else {
+			err = differ.diffEntry(entryTarget, name, module, previousEntry, afterEntry, ItemSchema(schema))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, afterEntry := range entries(after) {
+		entryTarget, ok := listEntryTarget(target, afterEntry, listKeys)
+		if !ok || findListEntry(previous, afterEntry, listKeys) != nil {
+			continue
+		}
+		err := differ.add(EditCreate, entryTarget, name, ajson.ArrayNode("", []*ajson.Node{afterEntry}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffEntry compares two versions of a list entry, replacing it when anything but its nested lists changed.
+func (differ *differ) diffEntry(target string, name string, module string, previous *ajson.Node, after *ajson.Node, schema *openapi3.Schema) error {
+	previousContent, err := withoutLists(previous, schema)
+	if err != nil {
+		return err
+	}
+	afterContent, err := withoutLists(after, schema)
+	if err != nil {
+		return err
+	}
+	equal, err := previousContent.Eq(afterContent)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		err = differ.add(EditReplace, target, name, ajson.ArrayNode("", []*ajson.Node{after}))
+		if err != nil {
+			return err
+		}
+	}
+	return differ.diffNestedLists(target, module, previous, after, schema)
+}
+
+// diffNestedLists compares the entries of the lists found in two versions of a list entry or of a container.
+func (differ *differ) diffNestedLists(target string, module string, previous *ajson.Node, after *ajson.Node, schema *openapi3.Schema) error {
+	for _, name := range memberNames(previous, after) {
+		previousChild, afterChild := member(previous, name), member(after, name)
+		childSchema := ChildSchema(schema, name)
+		var err error
+		switch {
+		case isKeyedList(previousChild, afterChild, childSchema):
+			err = differ.diffEntries(target+"/"+name, qualifiedName(name, module), moduleName(name, module), previousChild, afterChild, childSchema)
+		case (previousChild == nil || previousChild.IsObject()) && (afterChild == nil || afterChild.IsObject()):
+			err = differ.diffNestedLists(target+"/"+name, moduleName(name, module), previousChild, afterChild, childSchema)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutLists returns a copy of node without the keyed lists found in it, or in its containers.
+func withoutLists(node *ajson.Node, schema *openapi3.Schema) (*ajson.Node, error) {
+	clone, err := cloneNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return clone, removeLists(clone, schema)
+}
+
+func removeLists(node *ajson.Node, schema *openapi3.Schema) error {
+	if !node.IsObject() {
+		return nil
+	}
+	for _, key := range node.Keys() {
+		child, err := node.GetKey(key)
+		if err != nil {
+			return err
+		}
+		childSchema := ChildSchema(schema, key)
+		if isKeyedList(child, child, childSchema) {
+			err = node.DeleteKey(key)
+		} else {
+			err = removeLists(child, childSchema)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isKeyedList(previous *ajson.Node, after *ajson.Node, schema *openapi3.Schema) bool {
+	if previous == nil && after == nil {
+		return false
+	}
+	return (previous == nil || previous.IsArray()) && (after == nil || after.IsArray()) && len(ListKeys(schema)) > 0
+}
+
+// listEntryTarget appends the escaped key leaf values of entry to the path of its list, such as "/song=Bridge%20Burning".
+func listEntryTarget(target string, entry *ajson.Node, listKeys []string) (string, bool) {
+	values := make([]string, len(listKeys))
+	for i, listKey := range listKeys {
+		leaf, err := entry.GetKey(listKey)
+		if err != nil {
+			return "", false
+		}
+		value, ok := LeafString(leaf)
+		if !ok {
+			return "", false
+		}
+		values[i] = url.PathEscape(value)
+	}
+	return target + "=" + strings.Join(values, ","), true
+}
+
+func findListEntry(list *ajson.Node, entry *ajson.Node, listKeys []string) *ajson.Node {
+	if list == nil {
+		return nil
+	}
+	return findEntry(list, entry, listKeys)
+}
+
+func entries(list *ajson.Node) []*ajson.Node {
+	if list == nil {
+		return nil
+	}
+	entries, _ := list.GetArray()
+	return entries
+}
+
+func member(node *ajson.Node, name string) *ajson.Node {
+	if node == nil || !node.IsObject() {
+		return nil
+	}
+	child, err := node.GetKey(name)
+	if err != nil {
+		return nil
+	}
+	return child
+}
+
+// memberNames returns the sorted names of the members of either node.
+func memberNames(previous *ajson.Node, after *ajson.Node) []string {
+	names := map[string]bool{}
+	for _, node := range []*ajson.Node{previous, after} {
+		if node != nil && node.IsObject() {
+			for _, key := range node.Keys() {
+				names[key] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// moduleName returns the module of a member name, inherited from the parent node when the name is not qualified.
+func moduleName(name string, parentModule string) string {
+	if module, _, qualified := strings.Cut(name, ":"); qualified {
+		return module
+	}
+	return parentModule
+}
+
+func qualifiedName(name string, parentModule string) string {
+	if strings.Contains(name, ":") || parentModule == "" {
+		return name
+	}
+	return parentModule + ":" + name
+}
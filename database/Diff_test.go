@@ -0,0 +1,146 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+const networksSchemaJSON = `{
+	"type": "object",
+	"properties": {
+		"ietf-network:networks": {
+			"type": "object",
+			"properties": {
+				"network": {
+					"type": "array",
+					"x-key": "network-id",
+					"items": {
+						"type": "object",
+						"properties": {
+							"network-id": {"type": "string"},
+							"node": {
+								"type": "array",
+								"x-key": "node-id",
+								"items": {
+									"type": "object",
+									"properties": {
+										"node-id": {"type": "string"},
+										"name": {"type": "string"},
+										"ietf-network-topology:termination-point": {
+											"type": "array",
+											"x-key": "tp-id",
+											"items": {"type": "object", "properties": {"tp-id": {"type": "string"}}}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+func networksSchema() *openapi3.Schema {
+	var schema openapi3.Schema
+	_ = json.Unmarshal([]byte(networksSchemaJSON), &schema)
+	return &schema
+}
+
+func diffTargets(changes []Change) map[string]string {
+	targets := map[string]string{}
+	for _, change := range changes {
+		targets[change.Target] = change.Operation
+	}
+	return targets
+}
+
+func TestDiff_NodeLeafChanged_NodeReplaced(t *testing.T) {
+	previous := ajson.Must(ajson.Unmarshal([]byte(`{"ietf-network:networks":{"network":[{"network-id":"n1","node":[{"node-id":"a","name":"A","ietf-network-topology:termination-point":[{"tp-id":"1"}]}]}]}}`)))
+	after := ajson.Must(ajson.Unmarshal([]byte(`{"ietf-network:networks":{"network":[{"network-id":"n1","node":[{"node-id":"a","name":"B","ietf-network-topology:termination-point":[{"tp-id":"1"}]}]}]}}`)))
+
+	changes, err := Diff(previous, after, networksSchema())
+
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, EditReplace, changes[0].Operation)
+		assert.Equal(t, "/ietf-network:networks/network=n1/node=a", changes[0].Target)
+		value, _ := ajson.Marshal(changes[0].Value)
+		assert.JSONEq(t, `{"ietf-network:node":[{"node-id":"a","name":"B","ietf-network-topology:termination-point":[{"tp-id":"1"}]}]}`, string(value))
+	}
+}
+
+func TestDiff_NestedEntriesAddedAndRemoved_EntriesCreatedAndDeleted(t *testing.T) {
+	previous := ajson.Must(ajson.Unmarshal([]byte(`{"ietf-network:networks":{"network":[{"network-id":"n1","node":[{"node-id":"a","ietf-network-topology:termination-point":[{"tp-id":"1"}]},{"node-id":"b"}]}]}}`)))
+	after := ajson.Must(ajson.Unmarshal([]byte(`{"ietf-network:networks":{"network":[{"network-id":"n1","node":[{"node-id":"a","ietf-network-topology:termination-point":[{"tp-id":"1/2"}]}]}]}}`)))
+
+	changes, err := Diff(previous, after, networksSchema())
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"/ietf-network:networks/network=n1/node=a/ietf-network-topology:termination-point=1":     EditDelete,
+		"/ietf-network:networks/network=n1/node=a/ietf-network-topology:termination-point=1%2F2": EditCreate,
+		"/ietf-network:networks/network=n1/node=b":                                               EditDelete,
+	}, diffTargets(changes))
+}
+
+func TestDiff_ContainerLeafChanged_LeafReplaced(t *testing.T) {
+	previous := ajson.Must(ajson.Unmarshal([]byte(`{"example:system":{"hostname":"a","location":"x"}}`)))
+	after := ajson.Must(ajson.Unmarshal([]byte(`{"example:system":{"hostname":"b","location":"x"},"example:clock":{"timezone":"UTC"}}`)))
+
+	changes, err := Diff(previous, after, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"/example:system/hostname": EditReplace,
+		"/example:clock":           EditCreate,
+	}, diffTargets(changes))
+}
+
+func TestChange_Content_NestedEntryCreated_ContentWithAncestorKeys(t *testing.T) {
+	change := Change{
+		Operation: EditCreate,
+		Target:    "/ietf-network:networks/network=n1/node=a/ietf-network-topology:termination-point=1%2F2",
+		Value:     ajson.Must(ajson.Unmarshal([]byte(`{"ietf-network-topology:termination-point":[{"tp-id":"1/2"}]}`))),
+	}
+
+	content, err := change.Content(networksSchema())
+
+	assert.NoError(t, err)
+	marshal, _ := ajson.Marshal(content)
+	assert.JSONEq(t, `{"ietf-network:networks":{"network":[{"network-id":"n1","node":[{"node-id":"a","ietf-network-topology:termination-point":[{"tp-id":"1/2"}]}]}]}}`, string(marshal))
+}
+
+func TestChange_Content_EntryDeleted_ContentWithEntryKeys(t *testing.T) {
+	change := Change{Operation: EditDelete, Target: "/ietf-network:networks/network=n1/node=b"}
+
+	content, err := change.Content(networksSchema())
+
+	assert.NoError(t, err)
+	marshal, _ := ajson.Marshal(content)
+	assert.JSONEq(t, `{"ietf-network:networks":{"network":[{"network-id":"n1","node":[{"node-id":"b"}]}]}}`, string(marshal))
+}
+
+func TestChange_MarshalJSON_ChangeUnmarshaled_SameChange(t *testing.T) {
+	change := Change{
+		Operation: EditReplace,
+		Target:    "/example:system/hostname",
+		Value:     ajson.Must(ajson.Unmarshal([]byte(`{"example:hostname":"b"}`))),
+	}
+
+	marshal, err := json.Marshal(change)
+	var unmarshaled Change
+	unmarshalErr := json.Unmarshal(marshal, &unmarshaled)
+
+	assert.NoError(t, err)
+	assert.NoError(t, unmarshalErr)
+	assert.Equal(t, change.Operation, unmarshaled.Operation)
+	assert.Equal(t, change.Target, unmarshaled.Target)
+	value, _ := ajson.Marshal(unmarshaled.Value)
+	assert.JSONEq(t, `{"example:hostname":"b"}`, string(value))
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"github.com/spyzhov/ajson"
+)
+
+// Edit operations of a YANG Patch (RFC 8072 section 2.5).
+const (
+	EditCreate  = "create"
+	EditDelete  = "delete"
+	EditInsert  = "insert"
+	EditMerge   = "merge"
+	EditMove    = "move"
+	EditReplace = "replace"
+	EditRemove  = "remove"
+)
+
+// Edit is a single edit of a YANG Patch, resolved against the datastore.
+type Edit struct {
+	Operation string
+	KeyPath   KeyPath
+	// Value is the new value of the target node, a single entry array for list entries.
+	Value     *ajson.Node
+	Schema    *openapi3.Schema
+	Insertion Insertion
+}
+
+type DataMissingError struct {
+}
+
+func (e *DataMissingError) Error() string { return "Data Missing" }
+
+// ApplyEdits applies all the edits or none of them. It returns the index of the failing edit along with its error.
+func (db *Database) ApplyEdits(edits []Edit) (index int, err error) {
+	db.m.Lock()
+	defer db.m.Unlock()
+	content, err := cloneNode(db.Content)
+	if err != nil {
+		return -1, err
+	}
+	working := &Database{Content: content, metadata: copyMetadata(db.metadata)}
+	for i, edit := range edits {
+		err = working.applyEdit(edit)
+		if err != nil {
+			return i, err
+		}
+	}
+	db.Content = working.Content
+	db.metadata = working.metadata
+	return -1, nil
+}
+
+func (db *Database) applyEdit(edit Edit) error {
+	nodes, err := db.Content.JSONPath(edit.KeyPath)
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 1 {
+		return &KeyPathNotUniqueError{}
+	}
+	exists := len(nodes) == 1
+	switch edit.Operation {
+	case EditCreate, EditInsert:
+		if exists {
+			return &DataExistsError{}
+		}
+		_, err = db.Put(edit.KeyPath, edit.Value, edit.Insertion)
+	case EditMerge:
+		if exists {
+			err = db.Patch(edit.KeyPath, edit.Value, edit.Schema)
+		} else {
+			_, err = db.Put(edit.KeyPath, edit.Value, Insertion{})
+		}
+	case EditReplace:
+		_, err = db.Put(edit.KeyPath, edit.Value, Insertion{})
+	case EditDelete:
+		if !exists {
+			return &DataMissingError{}
+		}
+		err = db.Delete(edit.KeyPath)
+	case EditRemove:
+		if exists {
+			err = db.Delete(edit.KeyPath)
+		}
+	case EditMove:
+		if !exists {
+			return &DataMissingError{}
+		}
+		err = db.Move(edit.KeyPath, edit.Insertion)
+	default:
+		err = errors.Errorf("unknown edit operation '%s'", edit.Operation)
+	}
+	return err
+}
+
+// Move places the list entry at keyPath according to insertion.
+func (db *Database) Move(keyPath KeyPath, insertion Insertion) (err error) {
+	db.m.Lock()
+	defer db.m.Unlock()
+	nodes, err := db.Content.JSONPath(keyPath)
+	if err != nil {
+		return
+	}
+	if len(nodes) == 0 {
+		return &KeyPathNotFoundError{}
+	}
+	if len(nodes) > 1 {
+		return &KeyPathNotUniqueError{}
+	}
+	node := nodes[0]
+	list := node.Parent()
+	if list == nil || !list.IsArray() {
+		return errors.New("Only List Entries Can Be Moved")
+	}
+	err = node.Delete()
+	if err != nil {
+		return
+	}
+	err = insertEntry(list, node, insertion)
+	if err != nil {
+		return
+	}
+	return db.modified(keyPath)
+}
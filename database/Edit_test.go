@@ -0,0 +1,113 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+const jukeboxSchemaJSON = `{
+	"type": "object",
+	"properties": {
+		"playlist": {
+			"type": "array",
+			"x-key": "name",
+			"items": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"description": {"type": "string"}
+				}
+			}
+		}
+	}
+}`
+
+func jukeboxSchema() *openapi3.Schema {
+	var schema openapi3.Schema
+	_ = json.Unmarshal([]byte(jukeboxSchemaJSON), &schema)
+	return &schema
+}
+
+func TestResolveDataPath_ListEntryTarget_KeyPathWithFilter(t *testing.T) {
+	keyPath, schema, err := ResolveDataPath(`$["jukebox"]`, jukeboxSchema(), "/playlist=Foo%20One")
+
+	assert.NoError(t, err)
+	assert.Equal(t, `$["jukebox"]["playlist"][?(@["name"]=="Foo One")]`, keyPath)
+	assert.Equal(t, []string{"name"}, ListKeys(schema))
+}
+
+func TestResolveDataPath_UnknownNode_Error(t *testing.T) {
+	_, _, err := ResolveDataPath(`$["jukebox"]`, jukeboxSchema(), "/unknown")
+
+	assert.Error(t, err)
+}
+
+func TestDatabase_ApplyEdits_AllEditsSucceed_ChangesApplied(t *testing.T) {
+	db := newPlaylistDatabase()
+	edits := []Edit{
+		{Operation: EditCreate, KeyPath: `$["jukebox"]["playlist"][?(@["name"]=="X")]`, Value: ajson.Must(ajson.Unmarshal([]byte(`[{"name":"X"}]`)))},
+		{Operation: EditDelete, KeyPath: `$["jukebox"]["playlist"][?(@["name"]=="A")]`},
+		{Operation: EditMove, KeyPath: `$["jukebox"]["playlist"][?(@["name"]=="C")]`, Insertion: Insertion{Where: InsertFirst}},
+	}
+
+	index, err := db.ApplyEdits(edits)
+
+	assert.NoError(t, err)
+	assert.Equal(t, -1, index)
+	assert.Equal(t, []string{"C", "B", "X"}, playlistNames(db))
+}
+
+func TestDatabase_ApplyEdits_EditFails_NothingApplied(t *testing.T) {
+	db := newPlaylistDatabase()
+	edits := []Edit{
+		{Operation: EditDelete, KeyPath: `$["jukebox"]["playlist"][?(@["name"]=="A")]`},
+		{Operation: EditCreate, KeyPath: `$["jukebox"]["playlist"][?(@["name"]=="B")]`, Value: ajson.Must(ajson.Unmarshal([]byte(`[{"name":"B"}]`)))},
+	}
+
+	index, err := db.ApplyEdits(edits)
+
+	assert.IsType(t, &DataExistsError{}, err)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, []string{"A", "B", "C"}, playlistNames(db))
+}
+
+func TestDatabase_ApplyEdits_DeleteMissingData_DataMissingError(t *testing.T) {
+	db := newPlaylistDatabase()
+
+	index, err := db.ApplyEdits([]Edit{{Operation: EditDelete, KeyPath: `$["jukebox"]["playlist"][?(@["name"]=="Z")]`}})
+
+	assert.IsType(t, &DataMissingError{}, err)
+	assert.Equal(t, 0, index)
+}
+
+func TestDatabase_ApplyEdits_RemoveMissingData_NoError(t *testing.T) {
+	db := newPlaylistDatabase()
+
+	_, err := db.ApplyEdits([]Edit{{Operation: EditRemove, KeyPath: `$["jukebox"]["playlist"][?(@["name"]=="Z")]`}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A", "B", "C"}, playlistNames(db))
+}
+
+func TestDatabase_ApplyEdits_MergeExistingEntry_EntryMerged(t *testing.T) {
+	db := newPlaylistDatabase()
+	keyPath := `$["jukebox"]["playlist"][?(@["name"]=="B")]`
+	edit := Edit{
+		Operation: EditMerge,
+		KeyPath:   keyPath,
+		Value:     ajson.Must(ajson.Unmarshal([]byte(`[{"name":"B","description":"merged"}]`))),
+		Schema:    ChildSchema(jukeboxSchema(), "playlist"),
+	}
+
+	_, err := db.ApplyEdits([]Edit{edit})
+
+	assert.NoError(t, err)
+	nodes, _ := db.Content.JSONPath(keyPath + `["description"]`)
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, "merged", nodes[0].MustString())
+	}
+}
@@ -0,0 +1,217 @@
+package database
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spyzhov/ajson"
+	"regexp"
+	"strings"
+)
+
+// Fields is a parsed RESTCONF "fields" expression (RFC 8040 section 4.8.3).
+// Every selected child node name maps to the selection inside of it; a nil selection selects the whole subtree.
+type Fields map[string]Fields
+
+var apiIdentifierPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.-]*:)?[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+type fieldsParser struct {
+	expression string
+	position   int
+}
+
+// ParseFields parses expressions such as "node(node-id;ietf-network-topology:termination-point/tp-id)".
+func ParseFields(expression string) (Fields, error) {
+	parser := &fieldsParser{expression: expression}
+	fields, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(expression) {
+		return nil, parser.unexpected()
+	}
+	return fields, nil
+}
+
+func (parser *fieldsParser) parseExpression() (Fields, error) {
+	fields := Fields{}
+	for {
+		path, err := parser.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		var selection Fields
+		if parser.peek() == '(' {
+			parser.position++
+			selection, err = parser.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			if parser.peek() != ')' {
+				return nil, parser.unexpected()
+			}
+			parser.position++
+		}
+		fields.add(path, selection)
+		if parser.peek() != ';' {
+			return fields, nil
+		}
+		parser.position++
+	}
+}
+
+func (parser *fieldsParser) parsePath() (path []string, err error) {
+	for {
+		start := parser.position
+		for parser.position < len(parser.expression) && !strings.ContainsRune("/;()", rune(parser.expression[parser.position])) {
+			parser.position++
+		}
+		identifier := parser.expression[start:parser.position]
+		if !apiIdentifierPattern.MatchString(identifier) {
+			return nil, errors.Errorf("invalid identifier '%s' in fields expression", identifier)
+		}
+		path = append(path, identifier)
+		if parser.peek() != '/' {
+			return path, nil
+		}
+		parser.position++
+	}
+}
+
+func (parser *fieldsParser) peek() byte {
+	if parser.position < len(parser.expression) {
+		return parser.expression[parser.position]
+	}
+	return 0
+}
+
+func (parser *fieldsParser) unexpected() error {
+	if parser.position >= len(parser.expression) {
+		return errors.New("unexpected end of fields expression")
+	}
+	return errors.Errorf("unexpected '%c' at position %d of fields expression", parser.expression[parser.position], parser.position)
+}
+
+// add merges the selection at path, selecting the whole subtree takes precedence over a narrower selection.
+func (fields Fields) add(path []string, selection Fields) {
+	current := fields
+	for i, name := range path {
+		existing, exists := current[name]
+		if exists && existing == nil {
+			return
+		}
+		if i == len(path)-1 {
+			if selection == nil {
+				current[name] = nil
+				return
+			}
+			if !exists {
+				existing = Fields{}
+				current[name] = existing
+			}
+			for childName, childSelection := range selection {
+				existing.add([]string{childName}, childSelection)
+			}
+			return
+		}
+		if !exists {
+			existing = Fields{}
+			current[name] = existing
+		}
+		current = existing
+	}
+}
+
+func (fields Fields) lookup(key string) (selection Fields, found bool) {
+	selection, found = fields[key]
+	if found {
+		return
+	}
+	for name, candidate := range fields {
+		if LocalName(name) == LocalName(key) && (!strings.Contains(name, ":") || !strings.Contains(key, ":")) {
+			return candidate, true
+		}
+	}
+	return nil, false
+}
+
+// LocalName returns the name of a node without the module name prefixing it, such as "interface" for "ietf-interfaces:interface".
+func LocalName(name string) string {
+	return name[strings.LastIndex(name, ":")+1:]
+}
+
+// ProjectFields removes the children of node which are not selected by fields.
+// It returns the nodes selected as a whole, which are at depth 1 as far as the "depth" parameter is concerned.
+// Entries of a target list are kept even when nothing is selected inside of them.
+func ProjectFields(node *ajson.Node, fields Fields) (selected []*ajson.Node, err error) {
+	if !node.IsArray() {
+		_, selected, err = projectFields(node, fields)
+		return
+	}
+	for i := 0; i < node.Size(); i++ {
+		element, err := node.GetIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		_, elementSelected, err := projectFields(element, fields)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, elementSelected...)
+	}
+	return
+}
+
+func projectFields(node *ajson.Node, fields Fields) (keep bool, selected []*ajson.Node, err error) {
+	switch node.Type() {
+	case ajson.Array:
+		for i := node.Size() - 1; i >= 0; i-- {
+			element, err := node.GetIndex(i)
+			if err != nil {
+				return false, nil, err
+			}
+			keepElement, elementSelected, err := projectFields(element, fields)
+			if err != nil {
+				return false, nil, err
+			}
+			if !keepElement {
+				err = node.DeleteIndex(i)
+				if err != nil {
+					return false, nil, err
+				}
+				continue
+			}
+			selected = append(elementSelected, selected...)
+		}
+		return node.Size() > 0, selected, nil
+	case ajson.Object:
+		for _, key := range node.Keys() {
+			selection, found := fields.lookup(strings.TrimPrefix(key, "@"))
+			child, err := node.GetKey(key)
+			if err != nil {
+				return false, nil, err
+			}
+			keepChild := found
+			// metadata annotations such as "@leaf" follow the node they annotate
+			if found && !strings.HasPrefix(key, "@") {
+				if selection == nil {
+					selected = append(selected, child)
+				} else {
+					var childSelected []*ajson.Node
+					keepChild, childSelected, err = projectFields(child, selection)
+					if err != nil {
+						return false, nil, err
+					}
+					selected = append(selected, childSelected...)
+				}
+			}
+			if !keepChild {
+				err = node.DeleteKey(key)
+				if err != nil {
+					return false, nil, err
+				}
+			}
+		}
+		return node.Size() > 0, selected, nil
+	default:
+		return false, nil, nil
+	}
+}
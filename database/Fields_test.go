@@ -0,0 +1,89 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields_ValidExpression_FieldsTree(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		expected   Fields
+	}{
+		{
+			"single node",
+			"node",
+			Fields{"node": nil},
+		},
+		{
+			"sibling nodes",
+			"a;b",
+			Fields{"a": nil, "b": nil},
+		},
+		{
+			"nested path",
+			"a/b/c",
+			Fields{"a": Fields{"b": Fields{"c": nil}}},
+		},
+		{
+			"grouping",
+			"node(node-id;ietf-network-topology:termination-point/tp-id)",
+			Fields{"node": Fields{"node-id": nil, "ietf-network-topology:termination-point": Fields{"tp-id": nil}}},
+		},
+		{
+			"whole subtree takes precedence",
+			"a/b;a",
+			Fields{"a": nil},
+		},
+		{
+			"merged selections",
+			"a(b);a(c)",
+			Fields{"a": Fields{"b": nil, "c": nil}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields, err := ParseFields(test.expression)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, fields)
+		})
+	}
+}
+
+func TestParseFields_InvalidExpression_Error(t *testing.T) {
+	expressions := []string{"", "a;", "a(b", "a)b", "a//b", "1a", "a(b))"}
+	for _, expression := range expressions {
+		t.Run(expression, func(t *testing.T) {
+			fields, err := ParseFields(expression)
+
+			assert.Error(t, err)
+			assert.Nil(t, fields)
+		})
+	}
+}
+
+func TestProjectFields_GivenFields_OnlySelectedNodesKept(t *testing.T) {
+	node := ajson.Must(ajson.Unmarshal([]byte(`{
+		"network-id":"n1",
+		"node":[
+			{"node-id":"a","name":"A","ietf-network-topology:termination-point":[{"tp-id":"1","state":"up"}]},
+			{"node-id":"b","name":"B"}
+		],
+		"ietf-network-topology:link":[{"link-id":"l1"}]
+	}`)))
+	fields, _ := ParseFields("node(node-id;ietf-network-topology:termination-point/tp-id)")
+
+	selected, err := ProjectFields(node, fields)
+
+	assert.NoError(t, err)
+	assert.Len(t, selected, 3)
+	actual, _ := ajson.Marshal(node)
+	assert.JSONEq(t, `{"node":[
+		{"node-id":"a","ietf-network-topology:termination-point":[{"tp-id":"1"}]},
+		{"node-id":"b"}
+	]}`, string(actual))
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+	"strings"
+)
+
+// Filter selects the part of a datastore content a subscription is interested in.
+type Filter interface {
+	// Select returns a copy of the selected nodes of content along with their ancestors and the keys of the
+	// ancestor list entries, or nil when nothing is selected.
+	Select(content *ajson.Node, schema *openapi3.Schema) (*ajson.Node, error)
+}
+
+// SubtreeFilter is a subtree filter (RFC 6241 section 6) encoded in JSON, such as the "datastore-subtree-filter"
+// of a yang-push subscription. Empty objects are selection nodes, leaves with a value are content match nodes,
+// other objects are containment nodes and arrays list alternative filters for list entries.
+type SubtreeFilter struct {
+	filter *ajson.Node
+}
+
+func NewSubtreeFilter(filter *ajson.Node) *SubtreeFilter {
+	return &SubtreeFilter{filter: filter}
+}
+
+func (subtree *SubtreeFilter) Select(content *ajson.Node, schema *openapi3.Schema) (*ajson.Node, error) {
+	if content == nil {
+		return nil, nil
+	}
+	return selectSubtree(content, subtree.filter, schema, nil)
+}
+
+// selectSubtree applies the filter to node, keeping the key leaves when node is a list entry.
+func selectSubtree(node *ajson.Node, filter *ajson.Node, schema *openapi3.Schema, listKeys []string) (*ajson.Node, error) {
+	switch {
+	case filter.IsNull() || (filter.IsObject() && filter.Size() == 0):
+		return cloneNode(node)
+	case node.IsArray():
+		var selected []*ajson.Node
+		for _, entry := range entries(node) {
+			for _, alternative := range alternatives(filter) {
+				selection, err := selectSubtree(entry, alternative, ItemSchema(schema), ListKeys(schema))
+				if err != nil {
+					return nil, err
+				}
+				if selection != nil {
+					selected = append(selected, selection)
+					break
+				}
+			}
+		}
+		if len(selected) == 0 {
+			return nil, nil
+		}
+		return ajson.ArrayNode("", selected), nil
+	case !node.IsObject() || !filter.IsObject():
+		return nil, nil
+	}
+
+	result := ajson.ObjectNode("", map[string]*ajson.Node{})
+	selections := false
+	for _, name := range filter.Keys() {
+		filterChild, err := filter.GetKey(name)
+		if err != nil {
+			return nil, err
+		}
+		if filterChild.IsObject() || filterChild.IsArray() || filterChild.IsNull() {
+			selections = true
+			continue
+		}
+		key, child := matchingMember(node, name)
+		if child == nil || !leafEquals(child, filterChild) {
+			// every content match node must match for the node to be selected
+			return nil, nil
+		}
+		err = appendClone(result, key, child)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !selections {
+		return cloneNode(node)
+	}
+	selected := result.Size() > 0
+	for _, name := range filter.Keys() {
+		filterChild, err := filter.GetKey(name)
+		if err != nil {
+			return nil, err
+		}
+		key, child := matchingMember(node, name)
+		if child == nil || result.HasKey(key) {
+			continue
+		}
+		selection, err := selectSubtree(child, filterChild, ChildSchema(schema, key), nil)
+		if err != nil {
+			return nil, err
+		}
+		if selection != nil {
+			selected = true
+			err = result.AppendObject(key, selection)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !selected {
+		return nil, nil
+	}
+	return result, appendListKeys(result, node, listKeys)
+}
+
+func alternatives(filter *ajson.Node) []*ajson.Node {
+	if filter.IsArray() {
+		return entries(filter)
+	}
+	return []*ajson.Node{filter}
+}
+
+// matchingMember returns the member of node having the name, which is module-qualified or not.
+func matchingMember(node *ajson.Node, name string) (string, *ajson.Node) {
+	if child, err := node.GetKey(name); err == nil {
+		return name, child
+	}
+	for _, key := range node.Keys() {
+		if LocalName(key) == LocalName(name) && (!strings.Contains(key, ":") || !strings.Contains(name, ":")) {
+			child, _ := node.GetKey(key)
+			return key, child
+		}
+	}
+	return "", nil
+}
+
+func leafEquals(leaf *ajson.Node, value *ajson.Node) bool {
+	leafString, ok := LeafString(leaf)
+	if !ok {
+		return false
+	}
+	valueString, ok := LeafString(value)
+	return ok && leafString == valueString
+}
+
+func appendClone(target *ajson.Node, key string, node *ajson.Node) error {
+	clone, err := cloneNode(node)
+	if err != nil {
+		return err
+	}
+	return target.AppendObject(key, clone)
+}
+
+// appendListKeys copies the key leaves of the list entry to its selection, lest the selected entry cannot be identified.
+func appendListKeys(selection *ajson.Node, entry *ajson.Node, listKeys []string) error {
+	for _, listKey := range listKeys {
+		if selection.HasKey(listKey) {
+			continue
+		}
+		if leaf, err := entry.GetKey(listKey); err == nil {
+			err = appendClone(selection, listKey, leaf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
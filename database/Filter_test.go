@@ -0,0 +1,32 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+const networksContent = `{"ietf-network:networks":{"network":[
+	{"network-id":"n1","node":[{"node-id":"a","name":"A"},{"node-id":"b","name":"B"}]},
+	{"network-id":"n2","node":[{"node-id":"c","name":"C"}]}
+]}}`
+
+func TestSubtreeFilter_Select_ContentMatchAndSelection_MatchingEntriesWithKeys(t *testing.T) {
+	filter := NewSubtreeFilter(ajson.Must(ajson.Unmarshal([]byte(`{"ietf-network:networks":{"network":{"network-id":"n1","node":{"name":{}}}}}`))))
+
+	selection, err := filter.Select(ajson.Must(ajson.Unmarshal([]byte(networksContent))), networksSchema())
+
+	assert.NoError(t, err)
+	selected, _ := ajson.Marshal(selection)
+	assert.JSONEq(t, `{"ietf-network:networks":{"network":[{"network-id":"n1","node":[{"node-id":"a","name":"A"},{"node-id":"b","name":"B"}]}]}}`, string(selected))
+}
+
+func TestSubtreeFilter_Select_NoMatchingContent_Nil(t *testing.T) {
+	filter := NewSubtreeFilter(ajson.Must(ajson.Unmarshal([]byte(`{"ietf-network:networks":{"network":{"network-id":"n3"}}}`))))
+
+	selection, err := filter.Select(ajson.Must(ajson.Unmarshal([]byte(networksContent))), networksSchema())
+
+	assert.NoError(t, err)
+	assert.Nil(t, selection)
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spyzhov/ajson"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	InsertFirst  = "first"
+	InsertLast   = "last"
+	InsertBefore = "before"
+	InsertAfter  = "after"
+)
+
+// Insertion tells where an entry is placed in an ordered-by user list,
+// as requested by the RESTCONF "insert" and "point" query parameters.
+// The zero value appends new entries and leaves existing entries in place.
+type Insertion struct {
+	Where string
+	// Point holds the key leaf values of the entry of the insertion point, by key leaf name.
+	// A leaf-list entry is identified by its value under the empty name.
+	Point map[string]string
+}
+
+type PointNotFoundError struct {
+}
+
+func (e *PointNotFoundError) Error() string { return "Insertion Point Not Found" }
+
+// ParsePoint extracts the key leaf values of the entry of the list named listName identified by the instance path point,
+// such as "/example-jukebox:jukebox/playlist=Foo-One/song=1".
+func ParsePoint(point string, listName string, listKeys []string) (map[string]string, error) {
+	segments := strings.Split(strings.TrimSuffix(point, "/"), "/")
+	name, rawValues, found := strings.Cut(segments[len(segments)-1], "=")
+	if !found || LocalName(name) != LocalName(listName) {
+		return nil, errors.Errorf("point '%s' does not identify an entry of '%s'", point, listName)
+	}
+	values := strings.Split(rawValues, ",")
+	if len(listKeys) == 0 {
+		listKeys = []string{""}
+	}
+	if len(values) != len(listKeys) {
+		return nil, errors.Errorf("point '%s' does not match the keys of '%s'", point, listName)
+	}
+	keyValues := map[string]string{}
+	for i, listKey := range listKeys {
+		value, err := url.PathUnescape(values[i])
+		if err != nil {
+			return nil, err
+		}
+		keyValues[listKey] = value
+	}
+	return keyValues, nil
+}
+
+func insertEntry(list *ajson.Node, entry *ajson.Node, insertion Insertion) error {
+	if insertion.Where == "" || insertion.Where == InsertLast {
+		return list.AppendArray(entry)
+	}
+	entries := make([]*ajson.Node, 0, list.Size()+1)
+	for i := 0; i < list.Size(); i++ {
+		element, err := list.GetIndex(i)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, element)
+	}
+	index := 0
+	if insertion.Where != InsertFirst {
+		index = -1
+		for i, element := range entries {
+			if matchesPoint(element, insertion.Point) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return &PointNotFoundError{}
+		}
+		if insertion.Where == InsertAfter {
+			index++
+		}
+	}
+	entries = append(entries[:index], append([]*ajson.Node{entry}, entries[index:]...)...)
+	return list.SetArray(entries)
+}
+
+func matchesPoint(entry *ajson.Node, point map[string]string) bool {
+	for name, value := range point {
+		leaf := entry
+		if name != "" {
+			var err error
+			leaf, err = entry.GetKey(name)
+			if err != nil {
+				return false
+			}
+		}
+		leafValue, ok := LeafString(leaf)
+		if !ok || leafValue != value {
+			return false
+		}
+	}
+	return len(point) > 0
+}
+
+// LeafString returns the canonical string form of a leaf value, as used in list instance identifiers.
+func LeafString(leaf *ajson.Node) (string, bool) {
+	switch leaf.Type() {
+	case ajson.String:
+		value, err := leaf.GetString()
+		return value, err == nil
+	case ajson.Numeric:
+		value, err := leaf.GetNumeric()
+		return strconv.FormatFloat(value, 'f', -1, 64), err == nil
+	case ajson.Bool:
+		value, err := leaf.GetBool()
+		return strconv.FormatBool(value), err == nil
+	default:
+		return "", false
+	}
+}
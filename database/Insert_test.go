@@ -0,0 +1,69 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPlaylistDatabase() *Database {
+	return &Database{
+		Content: ajson.Must(ajson.Unmarshal([]byte(`{"jukebox":{"playlist":[{"name":"A"},{"name":"B"},{"name":"C"}]}}`))),
+	}
+}
+
+func playlistNames(db *Database) []string {
+	nodes, _ := db.Content.JSONPath(`$["jukebox"]["playlist"][*]["name"]`)
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i], _ = node.GetString()
+	}
+	return names
+}
+
+func TestDatabase_Post_GivenInsertion_EntryPlacedAtPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		insertion Insertion
+		expected  []string
+	}{
+		{"default", Insertion{}, []string{"A", "B", "C", "X"}},
+		{"first", Insertion{Where: InsertFirst}, []string{"X", "A", "B", "C"}},
+		{"last", Insertion{Where: InsertLast}, []string{"A", "B", "C", "X"}},
+		{"before", Insertion{Where: InsertBefore, Point: map[string]string{"name": "B"}}, []string{"A", "X", "B", "C"}},
+		{"after", Insertion{Where: InsertAfter, Point: map[string]string{"name": "B"}}, []string{"A", "B", "X", "C"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := newPlaylistDatabase()
+			entry := ajson.Must(ajson.Unmarshal([]byte(`[{"name":"X"}]`)))
+
+			appendKey, err := db.Post(`$["jukebox"]`, entry, "playlist", []string{"name"}, test.insertion)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "playlist=X", appendKey)
+			assert.Equal(t, test.expected, playlistNames(db))
+		})
+	}
+}
+
+func TestDatabase_Post_UnknownPoint_PointNotFoundError(t *testing.T) {
+	db := newPlaylistDatabase()
+	entry := ajson.Must(ajson.Unmarshal([]byte(`[{"name":"X"}]`)))
+
+	_, err := db.Post(`$["jukebox"]`, entry, "playlist", []string{"name"}, Insertion{Where: InsertAfter, Point: map[string]string{"name": "Z"}})
+
+	assert.IsType(t, &PointNotFoundError{}, err)
+}
+
+func TestDatabase_Put_ExistingEntryWithInsertion_EntryMoved(t *testing.T) {
+	db := newPlaylistDatabase()
+	entry := ajson.Must(ajson.Unmarshal([]byte(`[{"name":"C","description":"moved"}]`)))
+
+	created, err := db.Put(`$["jukebox"]["playlist"][?(@["name"]=="C")]`, entry, Insertion{Where: InsertFirst})
+
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, []string{"C", "A", "B"}, playlistNames(db))
+}
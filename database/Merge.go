@@ -0,0 +1,173 @@
+package database
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/spyzhov/ajson"
+)
+
+// MergeNode merges source into target the way the RESTCONF merge operation does:
+// objects are merged member by member, list entries are matched by their keys,
+// leaf-list values are added, and everything else is replaced.
+func MergeNode(target *ajson.Node, source *ajson.Node, schema *openapi3.Schema) error {
+	switch {
+	case target.IsObject() && source.IsObject():
+		for _, key := range source.Keys() {
+			sourceChild, err := source.GetKey(key)
+			if err != nil {
+				return err
+			}
+			targetChild, err := target.GetKey(key)
+			if err == nil && sameContainerType(targetChild, sourceChild) {
+				err = MergeNode(targetChild, sourceChild, ChildSchema(schema, key))
+			} else {
+				var clone *ajson.Node
+				clone, err = cloneNode(sourceChild)
+				if err == nil {
+					err = target.AppendObject(key, clone)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case target.IsArray() && source.IsArray():
+		return mergeEntries(target, source, schema)
+	default:
+		return replaceValue(target, source)
+	}
+}
+
+func sameContainerType(a *ajson.Node, b *ajson.Node) bool {
+	return (a.IsObject() && b.IsObject()) || (a.IsArray() && b.IsArray())
+}
+
+func mergeEntries(target *ajson.Node, source *ajson.Node, schema *openapi3.Schema) error {
+	listKeys := ListKeys(schema)
+	for i := 0; i < source.Size(); i++ {
+		sourceEntry, err := source.GetIndex(i)
+		if err != nil {
+			return err
+		}
+		targetEntry := findEntry(target, sourceEntry, listKeys)
+		if targetEntry == nil {
+			var clone *ajson.Node
+			clone, err = cloneNode(sourceEntry)
+			if err == nil {
+				err = target.AppendArray(clone)
+			}
+		} else if len(listKeys) > 0 {
+			err = MergeNode(targetEntry, sourceEntry, ItemSchema(schema))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findEntry returns the entry of list having the same key leaf values as entry, or the same value for leaf-lists.
+func findEntry(list *ajson.Node, entry *ajson.Node, listKeys []string) *ajson.Node {
+	point := map[string]string{}
+	if len(listKeys) == 0 {
+		value, ok := LeafString(entry)
+		if !ok {
+			return nil
+		}
+		point[""] = value
+	}
+	for _, listKey := range listKeys {
+		leaf, err := entry.GetKey(listKey)
+		if err != nil {
+			return nil
+		}
+		value, ok := LeafString(leaf)
+		if !ok {
+			return nil
+		}
+		point[listKey] = value
+	}
+	for i := 0; i < list.Size(); i++ {
+		candidate, err := list.GetIndex(i)
+		if err == nil && matchesPoint(candidate, point) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func replaceValue(target *ajson.Node, source *ajson.Node) error {
+	switch source.Type() {
+	case ajson.Null:
+		return target.SetNull()
+	case ajson.Bool:
+		value, err := source.GetBool()
+		if err != nil {
+			return err
+		}
+		return target.SetBool(value)
+	case ajson.Numeric:
+		value, err := source.GetNumeric()
+		if err != nil {
+			return err
+		}
+		return target.SetNumeric(value)
+	case ajson.String:
+		value, err := source.GetString()
+		if err != nil {
+			return err
+		}
+		return target.SetString(value)
+	case ajson.Array:
+		entries := make([]*ajson.Node, source.Size())
+		for i := range entries {
+			entry, err := source.GetIndex(i)
+			if err != nil {
+				return err
+			}
+			entries[i], err = cloneNode(entry)
+			if err != nil {
+				return err
+			}
+		}
+		return target.SetArray(entries)
+	default:
+		children := map[string]*ajson.Node{}
+		for _, key := range source.Keys() {
+			child, err := source.GetKey(key)
+			if err != nil {
+				return err
+			}
+			children[key], err = cloneNode(child)
+			if err != nil {
+				return err
+			}
+		}
+		return setObject(target, children)
+	}
+}
+
+// setObject replaces the value of target with an object of children.
+// Members are appended one by one, as SetObject shares a single key among all of them.
+func setObject(target *ajson.Node, children map[string]*ajson.Node) error {
+	err := target.SetObject(map[string]*ajson.Node{})
+	if err != nil {
+		return err
+	}
+	for key, child := range children {
+		err = target.AppendObject(key, child)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneNode deep copies node. Node.Clone leaves the descendants of the copy attached to the parents of the original ones.
+func cloneNode(node *ajson.Node) (*ajson.Node, error) {
+	marshal, err := ajson.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	return ajson.Unmarshal(marshal)
+}
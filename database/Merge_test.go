@@ -0,0 +1,30 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeNode_ListEntriesAndLeaves_MergedByKey(t *testing.T) {
+	target := ajson.Must(ajson.Unmarshal([]byte(`{"playlist":[{"name":"A","description":"a"},{"name":"B"}]}`)))
+	source := ajson.Must(ajson.Unmarshal([]byte(`{"playlist":[{"name":"B","description":"b"},{"name":"C"}]}`)))
+
+	err := MergeNode(target, source, jukeboxSchema())
+
+	assert.NoError(t, err)
+	merged, _ := ajson.Marshal(target)
+	assert.JSONEq(t, `{"playlist":[{"name":"A","description":"a"},{"name":"B","description":"b"},{"name":"C"}]}`, string(merged))
+}
+
+func TestMergeNode_LeafList_ValuesAdded(t *testing.T) {
+	target := ajson.Must(ajson.Unmarshal([]byte(`{"tags":["a","b"]}`)))
+	source := ajson.Must(ajson.Unmarshal([]byte(`{"tags":["b","c"]}`)))
+
+	err := MergeNode(target, source, nil)
+
+	assert.NoError(t, err)
+	merged, _ := ajson.Marshal(target)
+	assert.JSONEq(t, `{"tags":["a","b","c"]}`, string(merged))
+}
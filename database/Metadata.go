@@ -0,0 +1,209 @@
+package database
+
+import (
+	"encoding/json"
+	"github.com/gofrs/uuid"
+	"github.com/spyzhov/ajson"
+	"io/fs"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// legacy bookkeeping members once written into the root of the content
+const lastModifiedKey = "@@last-modified"
+const eTagKey = "@@etag"
+
+const metadataFileSuffix = ".metadata"
+
+// ResourceMetadata holds the entity tag and the modification time of a data resource (RFC 8040 sections 3.4.1.2 and 3.4.1.3).
+type ResourceMetadata struct {
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last-modified"`
+}
+
+// recordedMetadata is the metadata recorded for a resource. Its descendants which were not modified on their own
+// share the metadata it had when it was last replaced, kept in Descendants once it is modified through one of them.
+type recordedMetadata struct {
+	ResourceMetadata
+	Descendants *ResourceMetadata `json:"descendants,omitempty"`
+}
+
+// shared returns the metadata shared by the descendants of the resource which were not modified on their own.
+func (recorded recordedMetadata) shared() ResourceMetadata {
+	if recorded.Descendants != nil {
+		return *recorded.Descendants
+	}
+	return recorded.ResourceMetadata
+}
+
+var keyLeafFilterPattern = regexp.MustCompile(`@\["([^"]+)"\]==("([^"]*)"|[^|&)]+)`)
+
+// ResourcePath turns a key path into the path identifying its resource in the metadata, such as `/a/list[id=1]/b`.
+// The key paths selecting the same list entry by equal key leaf values lead to the same resource path.
+func ResourcePath(keyPath KeyPath) (string, error) {
+	segments, err := ajson.ParseJSONPath(keyPath)
+	if err != nil {
+		return "", err
+	}
+	var path strings.Builder
+	for _, segment := range segments[1:] {
+		if strings.HasPrefix(segment, "?") {
+			path.WriteString("[")
+			seen := map[string]bool{}
+			for _, match := range keyLeafFilterPattern.FindAllStringSubmatch(segment, -1) {
+				if seen[match[1]] {
+					continue
+				}
+				seen[match[1]] = true
+				if len(seen) > 1 {
+					path.WriteString(",")
+				}
+				value := match[2]
+				if strings.HasPrefix(value, "\"") {
+					value = match[3]
+				}
+				path.WriteString(match[1] + "=" + url.PathEscape(value))
+			}
+			path.WriteString("]")
+			continue
+		}
+		path.WriteString("/" + strings.Trim(segment, "\"'"))
+	}
+	return path.String(), nil
+}
+
+// Metadata returns the metadata of the resource at keyPath.
+// A resource which was not modified on its own shares the metadata of its closest modified ancestor,
+// as it was when that ancestor was last replaced.
+func (db *Database) Metadata(keyPath KeyPath) (ResourceMetadata, error) {
+	db.m.Lock()
+	defer db.m.Unlock()
+	path, err := ResourcePath(keyPath)
+	if err != nil {
+		return ResourceMetadata{}, err
+	}
+	if recorded, ok := db.metadata[path]; ok {
+		return recorded.ResourceMetadata, nil
+	}
+	return db.sharedMetadata(path), nil
+}
+
+// sharedMetadata returns the metadata that the resource at path shares with its closest recorded ancestor.
+func (db *Database) sharedMetadata(path string) ResourceMetadata {
+	for path != "" {
+		path = parentResourcePath(path)
+		if recorded, ok := db.metadata[path]; ok {
+			return recorded.shared()
+		}
+	}
+	return ResourceMetadata{}
+}
+
+// modified records a new entity tag and modification time for the resource at keyPath and all its ancestors.
+// The metadata of its descendants is forgotten, so that they share the new one, while the other descendants
+// of its ancestors keep theirs.
+func (db *Database) modified(keyPath KeyPath) error {
+	path, err := ResourcePath(keyPath)
+	if err != nil {
+		return err
+	}
+	v4, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	metadata := ResourceMetadata{ETag: "\"" + v4.String() + "\"", LastModified: time.Now().UTC()}
+	if db.metadata == nil {
+		db.metadata = map[string]recordedMetadata{}
+	}
+	for recorded := range db.metadata {
+		if strings.HasPrefix(recorded, path+"/") || strings.HasPrefix(recorded, path+"[") {
+			delete(db.metadata, recorded)
+		}
+	}
+	db.metadata[path] = recordedMetadata{ResourceMetadata: metadata}
+	for path != "" {
+		path = parentResourcePath(path)
+		ancestor := recordedMetadata{ResourceMetadata: metadata}
+		// the ancestors are updated from the closest one, so that the metadata shared so far is still recorded above
+		if recorded, ok := db.metadata[path]; ok {
+			shared := recorded.shared()
+			ancestor.Descendants = &shared
+		} else if shared := db.sharedMetadata(path); shared != (ResourceMetadata{}) {
+			ancestor.Descendants = &shared
+		}
+		db.metadata[path] = ancestor
+	}
+	return nil
+}
+
+func parentResourcePath(path string) string {
+	index := strings.LastIndexAny(path, "/[")
+	if index < 0 {
+		return ""
+	}
+	return path[:index]
+}
+
+func copyMetadata(metadata map[string]recordedMetadata) map[string]recordedMetadata {
+	copied := make(map[string]recordedMetadata, len(metadata))
+	for path, resourceMetadata := range metadata {
+		copied[path] = resourceMetadata
+	}
+	return copied
+}
+
+func loadMetadata(filename string) (map[string]recordedMetadata, error) {
+	fileContent, err := ioutil.ReadFile(filename + metadataFileSuffix)
+	if os.IsNotExist(err) {
+		return map[string]recordedMetadata{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var metadata map[string]recordedMetadata
+	err = json.Unmarshal(fileContent, &metadata)
+	if metadata == nil {
+		metadata = map[string]recordedMetadata{}
+	}
+	return metadata, err
+}
+
+func saveMetadata(filename string, metadata map[string]recordedMetadata) error {
+	fileData, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename+metadataFileSuffix, fileData, fs.ModePerm)
+}
+
+// migrateLegacyMetadata moves the global entity tag and modification time out of the content, to the root resource.
+func (db *Database) migrateLegacyMetadata() error {
+	if !db.Content.IsObject() || !db.Content.HasKey(eTagKey) {
+		return nil
+	}
+	if _, recorded := db.metadata[""]; !recorded {
+		eTag, _ := db.Content.GetKey(eTagKey)
+		metadata := ResourceMetadata{LastModified: time.Now().UTC()}
+		metadata.ETag, _ = eTag.GetString()
+		if lastModified, err := db.Content.GetKey(lastModifiedKey); err == nil {
+			value, _ := lastModified.GetString()
+			if parsed, err := time.Parse(time.RFC1123, value); err == nil {
+				metadata.LastModified = parsed.UTC()
+			}
+		}
+		db.metadata[""] = recordedMetadata{ResourceMetadata: metadata}
+	}
+	for _, key := range []string{eTagKey, lastModifiedKey} {
+		if db.Content.HasKey(key) {
+			err := db.Content.DeleteKey(key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourcePath_EquivalentKeyPaths_SameResourcePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyPath string
+	}{
+		{"numeric alternative", `$["a"]["list"][?((@["id"]=="1"||@["id"]==1))]["leaf"]`},
+		{"numeric", `$["a"]["list"][?(@["id"]==1)]["leaf"]`},
+		{"string", `$["a"]["list"][?(@["id"]=="1")]["leaf"]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := ResourcePath(test.keyPath)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "/a/list[id=1]/leaf", path)
+		})
+	}
+}
+
+func TestDatabase_Metadata_ChildModified_AncestorsUpdatedSiblingsKept(t *testing.T) {
+	db := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"a":{"x":1,"y":2}}`)))}
+	_ = db.Modified()
+	_ = db.modified(`$["a"]["y"]`)
+	before, _ := db.Metadata(`$["a"]["y"]`)
+
+	_, err := db.Put(`$["a"]["x"]`, ajson.NumericNode("", 3), Insertion{})
+
+	assert.NoError(t, err)
+	x, _ := db.Metadata(`$["a"]["x"]`)
+	a, _ := db.Metadata(`$["a"]`)
+	root, _ := db.Metadata(`$`)
+	y, _ := db.Metadata(`$["a"]["y"]`)
+	assert.NotEqual(t, before.ETag, x.ETag)
+	assert.Equal(t, x.ETag, a.ETag)
+	assert.Equal(t, x.ETag, root.ETag)
+	assert.Equal(t, before, y)
+}
+
+func TestDatabase_Metadata_ChildModified_UnwrittenSiblingKept(t *testing.T) {
+	db := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"a":{"x":1,"y":{"z":2}}}`)))}
+	_ = db.Modified()
+	before, _ := db.Metadata(`$["a"]["y"]`)
+	beforeDescendant, _ := db.Metadata(`$["a"]["y"]["z"]`)
+
+	_, err := db.Put(`$["a"]["x"]`, ajson.NumericNode("", 3), Insertion{})
+	_, secondErr := db.Put(`$["a"]["x"]`, ajson.NumericNode("", 4), Insertion{})
+
+	assert.NoError(t, err)
+	assert.NoError(t, secondErr)
+	y, _ := db.Metadata(`$["a"]["y"]`)
+	z, _ := db.Metadata(`$["a"]["y"]["z"]`)
+	a, _ := db.Metadata(`$["a"]`)
+	assert.NotEmpty(t, before.ETag)
+	assert.Equal(t, before, y)
+	assert.Equal(t, beforeDescendant, z)
+	assert.NotEqual(t, before.ETag, a.ETag)
+}
+
+func TestDatabase_Metadata_AncestorReplaced_DescendantsShareNewMetadata(t *testing.T) {
+	db := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"a":{"x":1}}`)))}
+	_ = db.modified(`$["a"]["x"]`)
+	before, _ := db.Metadata(`$["a"]["x"]`)
+
+	_, err := db.Put(`$["a"]`, ajson.Must(ajson.Unmarshal([]byte(`{"x":2}`))), Insertion{})
+
+	assert.NoError(t, err)
+	x, _ := db.Metadata(`$["a"]["x"]`)
+	a, _ := db.Metadata(`$["a"]`)
+	assert.NotEqual(t, before.ETag, x.ETag)
+	assert.Equal(t, a, x)
+}
+
+func TestLoad_LegacyBookkeepingMembers_MovedOutOfContent(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "database.json")
+	legacy := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(
+		`{"@@etag":"\"tag\"","@@last-modified":"Mon, 02 Jan 2006 15:04:05 UTC","a":{}}`,
+	)))}
+	_ = legacy.Save(filename)
+
+	db, err := Load(filename)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, db.Content.Keys())
+	metadata, _ := db.Metadata(`$["a"]`)
+	assert.Equal(t, `"tag"`, metadata.ETag)
+	assert.Equal(t, 2006, metadata.LastModified.Year())
+}
+
+func TestDatabase_Copy_CopyModified_OriginalKept(t *testing.T) {
+	db := &Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"a":{"x":1}}`)))}
+	_ = db.Modified()
+	metadata, _ := db.Metadata(`$["a"]`)
+
+	copied, err := db.Copy()
+	_, putErr := copied.Put(`$["a"]["x"]`, ajson.NumericNode("", 2), Insertion{})
+
+	assert.NoError(t, err)
+	assert.NoError(t, putErr)
+	original, _ := ajson.Marshal(db.Content)
+	assert.Equal(t, `{"a":{"x":1}}`, string(original))
+	modified, _ := ajson.Marshal(copied.Content)
+	assert.Equal(t, `{"a":{"x":2}}`, string(modified))
+	kept, _ := db.Metadata(`$["a"]`)
+	assert.Equal(t, metadata, kept)
+	changed, _ := copied.Metadata(`$["a"]`)
+	assert.NotEqual(t, metadata.ETag, changed.ETag)
+}
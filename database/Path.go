@@ -0,0 +1,64 @@
+package database
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ResolveDataPath appends the RESTCONF data path relative to the node at keyPath, such as "/song=Bridge%20Burning",
+// to keyPath. The key leaves of the traversed lists are looked up in the schema of that node.
+// It returns the resulting key path along with the schema of the last path segment.
+func ResolveDataPath(keyPath KeyPath, schema *openapi3.Schema, path string) (KeyPath, *openapi3.Schema, error) {
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		name, rawValues, isEntry := strings.Cut(segment, "=")
+		name, err := url.PathUnescape(name)
+		if err != nil {
+			return "", nil, err
+		}
+		schema = ChildSchema(schema, name)
+		if schema == nil {
+			return "", nil, errors.Errorf("unknown data node '%s'", name)
+		}
+		keyPath += "[\"" + name + "\"]"
+		if !isEntry {
+			continue
+		}
+		listKeys := ListKeys(schema)
+		values := strings.Split(rawValues, ",")
+		if len(listKeys) != len(values) {
+			return "", nil, errors.Errorf("list '%s' has %d keys but %d values are given", name, len(listKeys), len(values))
+		}
+		filter, err := listEntryFilter(listKeys, values)
+		if err != nil {
+			return "", nil, err
+		}
+		keyPath += filter
+	}
+	return keyPath, schema, nil
+}
+
+// listEntryFilter builds the JSONPath filter selecting the list entry with the given escaped key leaf values.
+func listEntryFilter(listKeys []string, rawValues []string) (string, error) {
+	filter := "[?("
+	for i, listKey := range listKeys {
+		if i > 0 {
+			filter += "&&"
+		}
+		value, err := url.PathUnescape(rawValues[i])
+		if err != nil {
+			return "", err
+		}
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			filter += "(@[\"" + listKey + "\"]==\"" + value + "\"||@[\"" + listKey + "\"]==" + value + ")"
+		} else {
+			filter += "@[\"" + listKey + "\"]==\"" + value + "\""
+		}
+	}
+	return filter + ")]", nil
+}
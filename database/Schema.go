@@ -0,0 +1,108 @@
+package database
+
+import (
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"strings"
+)
+
+const configExtension = "x-config"
+const keyExtension = "x-key"
+const orderedByExtension = "x-ordered-by"
+
+// ChildSchema returns the schema of the child node named key of an object or list schema,
+// looking into combined schemas as well. It returns nil if the child is unknown.
+func ChildSchema(schema *openapi3.Schema, key string) *openapi3.Schema {
+	if schema == nil {
+		return nil
+	}
+	schema = ItemSchema(schema)
+	if property, ok := schema.Properties[key]; ok && property.Value != nil {
+		return property.Value
+	}
+	for name, property := range schema.Properties {
+		if property.Value != nil && LocalName(name) == LocalName(key) && (!strings.Contains(name, ":") || !strings.Contains(key, ":")) {
+			return property.Value
+		}
+	}
+	for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		for _, ref := range refs {
+			if child := ChildSchema(ref.Value, key); child != nil {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// ItemSchema returns the schema of the entries of a list or leaf-list schema, or the schema itself otherwise.
+func ItemSchema(schema *openapi3.Schema) *openapi3.Schema {
+	if schema != nil && schema.Items != nil && schema.Items.Value != nil {
+		return schema.Items.Value
+	}
+	return schema
+}
+
+// IsConfig tells whether a node described by schema is configuration data.
+// The flag is read from the "x-config" extension or from "readOnly", and is inherited from the parent node otherwise.
+func IsConfig(schema *openapi3.Schema, inherited bool) bool {
+	if schema == nil {
+		return inherited
+	}
+	if !inherited {
+		return false
+	}
+	var config interface{}
+	if extensionValue(schema, configExtension, &config) {
+		switch value := config.(type) {
+		case bool:
+			return value
+		case string:
+			return value != "false"
+		}
+	}
+	if items := schema.Items; items != nil && items.Value != nil && items.Value.ReadOnly {
+		return false
+	}
+	return !schema.ReadOnly
+}
+
+// ListKeys returns the key leaf names of a list schema declared by the "x-key" extension.
+func ListKeys(schema *openapi3.Schema) []string {
+	var xKey string
+	if schema == nil {
+		return nil
+	}
+	if !extensionValue(schema, keyExtension, &xKey) && !extensionValue(ItemSchema(schema), keyExtension, &xKey) {
+		return nil
+	}
+	if xKey == "" {
+		return nil
+	}
+	return strings.Split(xKey, ",")
+}
+
+// IsOrderedByUser tells whether the entries of a list or leaf-list schema are ordered by the user,
+// as declared by the "x-ordered-by" extension. The entries are ordered by the system otherwise.
+func IsOrderedByUser(schema *openapi3.Schema) bool {
+	var orderedBy string
+	if schema == nil {
+		return false
+	}
+	if !extensionValue(schema, orderedByExtension, &orderedBy) && !extensionValue(ItemSchema(schema), orderedByExtension, &orderedBy) {
+		return false
+	}
+	return orderedBy == "user"
+}
+
+func extensionValue(schema *openapi3.Schema, name string, target interface{}) bool {
+	raw, ok := schema.Extensions[name]
+	if !ok {
+		return false
+	}
+	message, ok := raw.(json.RawMessage)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(message, target) == nil
+}
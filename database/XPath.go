@@ -0,0 +1,216 @@
+package database
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"github.com/spyzhov/ajson"
+	"regexp"
+	"strings"
+)
+
+// XPathFilter is an XPath filter, such as the "datastore-xpath-filter" of a yang-push subscription.
+// The supported expressions are unions of absolute location paths, whose steps are node names or "*",
+// each followed by predicates comparing child leaves to literals, such as "/ietf-network:networks/network[network-id='n1']/node".
+type XPathFilter struct {
+	paths [][]xpathStep
+}
+
+type xpathStep struct {
+	name       string
+	predicates map[string]string // child leaf name -> value
+}
+
+var xpathNamePattern = regexp.MustCompile(`^(\*|([A-Za-z_][A-Za-z0-9_.-]*:)?[A-Za-z_][A-Za-z0-9_.-]*)`)
+var xpathComparisonPattern = regexp.MustCompile(`^\s*(([A-Za-z_][A-Za-z0-9_.-]*:)?[A-Za-z_][A-Za-z0-9_.-]*)\s*=\s*('[^']*'|"[^"]*"|-?[0-9]+(\.[0-9]+)?)\s*`)
+
+type xpathParser struct {
+	expression string
+	position   int
+}
+
+func ParseXPathFilter(expression string) (*XPathFilter, error) {
+	parser := &xpathParser{expression: expression}
+	filter := &XPathFilter{}
+	for {
+		path, err := parser.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		filter.paths = append(filter.paths, path)
+		parser.skipSpaces()
+		if parser.peek() != '|' {
+			break
+		}
+		parser.position++
+	}
+	if parser.position < len(expression) {
+		return nil, parser.unexpected()
+	}
+	return filter, nil
+}
+
+func (parser *xpathParser) parsePath() (path []xpathStep, err error) {
+	parser.skipSpaces()
+	for parser.peek() == '/' {
+		parser.position++
+		name := xpathNamePattern.FindString(parser.expression[parser.position:])
+		if name == "" {
+			return nil, parser.unexpected()
+		}
+		parser.position += len(name)
+		step := xpathStep{name: name, predicates: map[string]string{}}
+		for parser.peek() == '[' {
+			parser.position++
+			err = parser.parsePredicate(step.predicates)
+			if err != nil {
+				return nil, err
+			}
+		}
+		path = append(path, step)
+	}
+	if len(path) == 0 {
+		return nil, parser.unexpected()
+	}
+	return path, nil
+}
+
+// parsePredicate parses comparisons joined by "and" up to the closing bracket.
+func (parser *xpathParser) parsePredicate(predicates map[string]string) error {
+	for {
+		match := xpathComparisonPattern.FindStringSubmatch(parser.expression[parser.position:])
+		if match == nil {
+			return parser.unexpected()
+		}
+		parser.position += len(match[0])
+		predicates[match[1]] = strings.Trim(match[3], `'"`)
+		if parser.peek() == ']' {
+			parser.position++
+			return nil
+		}
+		if !strings.HasPrefix(parser.expression[parser.position:], "and ") {
+			return parser.unexpected()
+		}
+		parser.position += len("and ")
+	}
+}
+
+func (parser *xpathParser) skipSpaces() {
+	for parser.peek() == ' ' {
+		parser.position++
+	}
+}
+
+func (parser *xpathParser) peek() byte {
+	if parser.position < len(parser.expression) {
+		return parser.expression[parser.position]
+	}
+	return 0
+}
+
+func (parser *xpathParser) unexpected() error {
+	if parser.position >= len(parser.expression) {
+		return errors.New("unexpected end of XPath expression")
+	}
+	return errors.Errorf("unexpected '%c' at position %d of XPath expression", parser.expression[parser.position], parser.position)
+}
+
+// Select merges the selections of the location paths of the union.
+func (filter *XPathFilter) Select(content *ajson.Node, schema *openapi3.Schema) (*ajson.Node, error) {
+	if content == nil {
+		return nil, nil
+	}
+	var result *ajson.Node
+	for _, path := range filter.paths {
+		selection, err := selectXPath(content, path, schema, nil)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case selection == nil:
+		case result == nil:
+			result = selection
+		default:
+			err = MergeNode(result, selection, schema)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// selectXPath selects the nodes at the end of the steps from the children of node,
+// keeping the key leaves when node is a list entry.
+func selectXPath(node *ajson.Node, steps []xpathStep, schema *openapi3.Schema, listKeys []string) (*ajson.Node, error) {
+	if !node.IsObject() {
+		return nil, nil
+	}
+	step := steps[0]
+	result := ajson.ObjectNode("", map[string]*ajson.Node{})
+	for _, key := range node.Keys() {
+		if !step.matches(key) {
+			continue
+		}
+		child, err := node.GetKey(key)
+		if err != nil {
+			return nil, err
+		}
+		childSchema := ChildSchema(schema, key)
+		var selection *ajson.Node
+		if child.IsArray() {
+			var selected []*ajson.Node
+			for _, entry := range entries(child) {
+				entrySelection, err := step.selectNode(entry, steps[1:], ItemSchema(childSchema), ListKeys(childSchema))
+				if err != nil {
+					return nil, err
+				}
+				if entrySelection != nil {
+					selected = append(selected, entrySelection)
+				}
+			}
+			if len(selected) > 0 {
+				selection = ajson.ArrayNode("", selected)
+			}
+		} else {
+			selection, err = step.selectNode(child, steps[1:], childSchema, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if selection != nil {
+			err = result.AppendObject(key, selection)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if result.Size() == 0 {
+		return nil, nil
+	}
+	return result, appendListKeys(result, node, listKeys)
+}
+
+// selectNode selects node as a whole at the last step, or the nodes selected by the remaining steps otherwise.
+func (step xpathStep) selectNode(node *ajson.Node, remaining []xpathStep, schema *openapi3.Schema, listKeys []string) (*ajson.Node, error) {
+	for name, value := range step.predicates {
+		_, leaf := matchingMember(node, name)
+		if leaf == nil {
+			return nil, nil
+		}
+		if leafValue, ok := LeafString(leaf); !ok || leafValue != value {
+			return nil, nil
+		}
+	}
+	if len(remaining) == 0 {
+		return cloneNode(node)
+	}
+	return selectXPath(node, remaining, schema, listKeys)
+}
+
+// matches tells whether the step selects the member, the prefix of the step being the name of the module.
+func (step xpathStep) matches(key string) bool {
+	if step.name == "*" || step.name == key {
+		return true
+	}
+	return LocalName(step.name) == LocalName(key) && (!strings.Contains(step.name, ":") || !strings.Contains(key, ":"))
+}
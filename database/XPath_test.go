@@ -0,0 +1,34 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXPathFilter_Select_PredicateOnListEntry_MatchingEntryWithKeys(t *testing.T) {
+	filter, err := ParseXPathFilter("/ietf-network:networks/network/node[node-id='c']/name")
+
+	assert.NoError(t, err)
+	selection, err := filter.Select(ajson.Must(ajson.Unmarshal([]byte(networksContent))), networksSchema())
+	assert.NoError(t, err)
+	selected, _ := ajson.Marshal(selection)
+	assert.JSONEq(t, `{"ietf-network:networks":{"network":[{"network-id":"n2","node":[{"node-id":"c","name":"C"}]}]}}`, string(selected))
+}
+
+func TestXPathFilter_Select_Union_SelectionsMerged(t *testing.T) {
+	filter, err := ParseXPathFilter(`/ietf-network:networks/network[network-id="n1"]/node[node-id='a'] | /ietf-network:networks/network[network-id="n1"]/node[node-id='b' and name='B']`)
+
+	assert.NoError(t, err)
+	selection, err := filter.Select(ajson.Must(ajson.Unmarshal([]byte(networksContent))), networksSchema())
+	assert.NoError(t, err)
+	selected, _ := ajson.Marshal(selection)
+	assert.JSONEq(t, `{"ietf-network:networks":{"network":[{"network-id":"n1","node":[{"node-id":"a","name":"A"},{"node-id":"b","name":"B"}]}]}}`, string(selected))
+}
+
+func TestParseXPathFilter_DescendantAxis_Error(t *testing.T) {
+	_, err := ParseXPathFilter("//node")
+
+	assert.EqualError(t, err, "unexpected '/' at position 1 of XPath expression")
+}
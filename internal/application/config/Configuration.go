@@ -34,7 +34,26 @@ type Configuration struct {
 	SuppressErrors  bool
 	DatabasePath    string
 	GrpcPort        uint16
-	SSEInterval     uint64
+	// SSEInterval is the number of seconds between the heartbeats of the notification streams, 0 disabling them
+	SSEInterval   uint64
+	XMLNamespaces map[string]string
+	// PropagationDelay is the time taken by a change of the running datastore to reach the intended and operational ones
+	PropagationDelay time.Duration
+	// Operations maps the paths of RPCs and actions to their configured responses
+	Operations map[string]OperationResponse
+	// SuppressEcho keeps the changes a client writes to the running datastore from being notified to its own subscriptions
+	SuppressEcho bool
+	// EventLogSize is the number of events retained for replay, saved to the file at EventLogPath unless it is empty
+	EventLogSize uint64
+	EventLogPath string
+}
+
+// OperationResponse describes the response of an RPC or an action:
+// the content of File, the output of Template, or else the output generated from the schema of the operation.
+type OperationResponse struct {
+	StatusCode int
+	File       string
+	Template   string
 }
 
 const (
@@ -48,6 +67,7 @@ const (
 	DefaultMinFloat        = -float64(math.MaxInt32 / 2)
 	DefaultMaxFloat        = float64(math.MaxInt32 / 2)
 	DefaultSSEInterval     = uint64(15)
+	DefaultEventLogSize    = uint64(1000)
 )
 
 func (config *Configuration) Dump() map[string]interface{} {
@@ -68,5 +88,9 @@ func (config *Configuration) Dump() map[string]interface{} {
 		"DefaultMaxFloat":  config.DefaultMaxFloat,
 		"SuppressErrors":   config.SuppressErrors,
 		"DatabasePath":     config.DatabasePath,
+		"PropagationDelay": config.PropagationDelay,
+		"SuppressEcho":     config.SuppressEcho,
+		"EventLogSize":     config.EventLogSize,
+		"EventLogPath":     config.EventLogPath,
 	}
 }
@@ -103,7 +103,26 @@ func createApplicationConfiguration(fileConfig *fileConfiguration) *Configuratio
 		SuppressErrors:  fileConfig.Generation.SuppressErrors,
 		GrpcPort:        defaultOnNilUint16(fileConfig.GrpcPort, DefaultGrpcPort),
 		SSEInterval:     defaultOnNilUint64(fileConfig.SSEInterval, DefaultSSEInterval),
+		XMLNamespaces:   fileConfig.XMLNamespaces,
+
+		PropagationDelay: time.Duration(fileConfig.PropagationDelay * float64(time.Second)),
+		Operations:       createOperationResponses(fileConfig.Operations),
+		SuppressEcho:     fileConfig.SuppressEcho,
+		EventLogSize:     defaultOnNilUint64(fileConfig.EventLogSize, DefaultEventLogSize),
+		EventLogPath:     fileConfig.EventLogPath,
+	}
+}
+
+func createOperationResponses(operations map[string]operationConfiguration) map[string]OperationResponse {
+	responses := make(map[string]OperationResponse, len(operations))
+	for path, operation := range operations {
+		responses[path] = OperationResponse{
+			StatusCode: operation.Status,
+			File:       operation.File,
+			Template:   operation.Template,
+		}
 	}
+	return responses
 }
 
 func parseLogLevel(rawLogLevel string) logrus.Level {
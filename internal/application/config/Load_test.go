@@ -192,3 +192,14 @@ func assertIsDefaultConfiguration(t *testing.T, config *Configuration) {
 	assert.False(t, config.SuppressErrors)
 	assert.Equal(t, data.No, config.UseExamples)
 }
+
+func TestLoad_FileWithInvalidOperations_Error(t *testing.T) {
+	resetEnvironment()
+
+	config, err := Load("./../../../test/resources/invalid-operations-config.yaml")
+
+	assert.Nil(t, config)
+	assert.EqualError(t, err, "configuration has invalid values: "+
+		"invalid option 'operations./restconf/operations/example:ping': "+
+		"status: must be no greater than 599; template: must be blank when a file is given.")
+}
@@ -24,6 +24,11 @@ type environmentConfiguration struct {
 
 	GrpcPort    *uint16 `split_words:"true"`
 	SSEInterval *uint64 `split_words:"true"`
+
+	PropagationDelay *float64 `split_words:"true"`
+	SuppressEcho     *bool    `split_words:"true"`
+	EventLogSize     *uint64  `split_words:"true"`
+	EventLogPath     *string  `split_words:"true"`
 }
 
 func updateConfigFromEnvironment(fileConfig *fileConfiguration) {
@@ -53,6 +58,10 @@ func updateConfigFromEnvironment(fileConfig *fileConfiguration) {
 	fileConfig.Generation.UseExamples = coalesceString(fileConfig.Generation.UseExamples, envConfig.UseExamples)
 	fileConfig.GrpcPort = coalesceUint16(fileConfig.GrpcPort, envConfig.GrpcPort)
 	fileConfig.SSEInterval = coalesceUint64(fileConfig.SSEInterval, envConfig.SSEInterval)
+	fileConfig.PropagationDelay = *coalesceFloat(&fileConfig.PropagationDelay, envConfig.PropagationDelay)
+	fileConfig.SuppressEcho = coalesceBool(fileConfig.SuppressEcho, envConfig.SuppressEcho)
+	fileConfig.EventLogSize = coalesceUint64(fileConfig.EventLogSize, envConfig.EventLogSize)
+	fileConfig.EventLogPath = coalesceString(fileConfig.EventLogPath, envConfig.EventLogPath)
 }
 
 func coalesceString(v1 string, v2 *string) string {
@@ -16,6 +16,24 @@ type fileConfiguration struct {
 	Generation  generationConfiguration  `json:"generation" yaml:"generation"`
 	GrpcPort    *uint16                  `json:"grpc_port" yaml:"grpc_port"`
 	SSEInterval *uint64                  `json:"sse_interval" yaml:"sse_interval"`
+	// XMLNamespaces maps YANG module names to the XML namespaces which do not follow the IETF convention
+	XMLNamespaces map[string]string `json:"xml_namespaces" yaml:"xml_namespaces"`
+	// PropagationDelay is the number of seconds a change of the running datastore takes to reach the intended and operational ones
+	PropagationDelay float64 `json:"propagation_delay" yaml:"propagation_delay"`
+	// Operations maps the paths of RPCs and actions, as requested or as written in the specification, to their responses
+	Operations map[string]operationConfiguration `json:"operations" yaml:"operations"`
+	// SuppressEcho keeps the changes a client writes to the running datastore from being notified to its own subscriptions
+	SuppressEcho bool `json:"suppress_echo" yaml:"suppress_echo"`
+	// EventLogSize is the number of events retained for the subscriptions replaying them
+	EventLogSize *uint64 `json:"event_log_size" yaml:"event_log_size"`
+	// EventLogPath is the file the retained events are saved to, the events being kept in memory only when it is empty
+	EventLogPath string `json:"event_log_path" yaml:"event_log_path"`
+}
+
+type operationConfiguration struct {
+	Status   int    `json:"status" yaml:"status"`
+	File     string `json:"file" yaml:"file"`
+	Template string `json:"template" yaml:"template"`
 }
 
 type openapiConfiguration struct {
@@ -78,7 +96,7 @@ func stringsAsInterfaces(ss []string) []interface{} {
 }
 
 func (config *fileConfiguration) Validate() error {
-	return validation.Errors{
+	errors := validation.Errors{
 		"http.port": validation.Validate(
 			config.HTTP.Port,
 			validation.Required.When(config.HTTP.Port != nil),
@@ -97,5 +115,23 @@ func (config *fileConfiguration) Validate() error {
 			config.Generation.UseExamples,
 			validation.In(stringsAsInterfaces(useExampleOptions)...).Error(invalidUseExample),
 		),
-	}.Filter()
+	}
+	for path, operation := range config.Operations {
+		errors["operations."+path] = operation.Validate()
+	}
+	return errors.Filter()
+}
+
+func (operation operationConfiguration) Validate() error {
+	return validation.ValidateStruct(&operation,
+		validation.Field(
+			&operation.Status,
+			validation.Min(100),
+			validation.Max(599),
+		),
+		validation.Field(
+			&operation.Template,
+			validation.Empty.When(operation.File != "").Error("must be blank when a file is given"),
+		),
+	)
 }
@@ -67,10 +67,19 @@ func (factory *Factory) CreateHTTPHandler(router *legacy.Router) http.Handler {
 
 	dataGeneratorInstance := data.New(generatorOptions)
 	responseGeneratorInstance := responseGenerator.New(dataGeneratorInstance)
-	apiResponder := responder.New()
+	apiResponder := responder.New(factory.configuration.XMLNamespaces)
+
+	operations := map[string]handler.OperationResponse{}
+	for path, operation := range factory.configuration.Operations {
+		operations[path] = handler.OperationResponse{
+			StatusCode: operation.StatusCode,
+			File:       operation.File,
+			Template:   operation.Template,
+		}
+	}
 
 	var httpHandler http.Handler
-	httpHandler = handler.NewResponseGeneratorHandler(router, responseGeneratorInstance, apiResponder, factory.configuration.DatabasePath, factory.configuration.GrpcPort, factory.configuration.SSEInterval)
+	httpHandler = handler.NewResponseGeneratorHandler(router, responseGeneratorInstance, apiResponder, factory.configuration.DatabasePath, factory.configuration.GrpcPort, factory.configuration.SSEInterval, factory.configuration.XMLNamespaces, factory.configuration.PropagationDelay, operations, factory.configuration.SuppressEcho, factory.configuration.EventLogSize, factory.configuration.EventLogPath)
 	if factory.configuration.CORSEnabled {
 		httpHandler = middleware.CORSHandler(httpHandler)
 	}
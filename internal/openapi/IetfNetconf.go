@@ -0,0 +1,54 @@
+package openapi
+
+import "encoding/json"
+
+// DefaultConfirmTimeout is the number of seconds a confirmed commit waits for its confirmation (RFC 6241 section 8.4.5.1).
+const DefaultConfirmTimeout = 600
+
+// NetconfCommitInput is the input of the ietf-netconf "commit" and "cancel-commit" operations.
+// The "confirmed" leaf is of type empty, it is set whenever present.
+type NetconfCommitInput struct {
+	Input struct {
+		Confirmed      json.RawMessage `json:"confirmed"`
+		ConfirmTimeout json.Number     `json:"confirm-timeout"`
+		Persist        string          `json:"persist"`
+		PersistID      *string         `json:"persist-id"`
+	} `json:"ietf-netconf:input"`
+}
+
+// NetconfLockInput is the input of the ietf-netconf "lock" and "unlock" operations,
+// whose target is one of the "running" and "candidate" empty leaves.
+type NetconfLockInput struct {
+	Input struct {
+		Target struct {
+			Running   json.RawMessage `json:"running"`
+			Candidate json.RawMessage `json:"candidate"`
+		} `json:"target"`
+	} `json:"ietf-netconf:input"`
+}
+
+func (input NetconfCommitInput) IsConfirmed() bool {
+	return len(input.Input.Confirmed) > 0
+}
+
+// Datastore returns the identity of the target datastore, or an empty string if there is none.
+func (input NetconfLockInput) Datastore() string {
+	switch {
+	case len(input.Input.Target.Running) > 0:
+		return DatastoreRunning
+	case len(input.Input.Target.Candidate) > 0:
+		return DatastoreCandidate
+	}
+	return ""
+}
+
+// LockDeniedError tells that the datastore is locked by another client.
+// Clients of RESTCONF have no NETCONF session, hence the session-id 0 of the lock holder.
+func LockDeniedError(datastore string) RestconfError {
+	return RestconfError{
+		ErrorType:    ErrorTypeProtocol,
+		ErrorTag:     ErrorTagLockDenied,
+		ErrorMessage: "The datastore '" + datastore + "' is locked by another client",
+		ErrorInfo:    map[string]uint32{"session-id": 0},
+	}
+}
@@ -0,0 +1,27 @@
+package openapi
+
+// YangLibraryVersion is the revision date of the ietf-yang-library module implemented by the server.
+const YangLibraryVersion = "2019-01-04"
+
+// RestconfRootWrapped is the top-level "{+restconf}" resource (RFC 8040 section 3.3).
+type RestconfRootWrapped struct {
+	Restconf RestconfRoot `json:"ietf-restconf:restconf"`
+}
+
+type RestconfRoot struct {
+	Data               struct{} `json:"data"`
+	Operations         struct{} `json:"operations"`
+	YangLibraryVersion string   `json:"yang-library-version"`
+}
+
+type YangLibraryVersionWrapped struct {
+	YangLibraryVersion string `json:"ietf-restconf:yang-library-version"`
+}
+
+func NewRestconfRoot() RestconfRootWrapped {
+	return RestconfRootWrapped{Restconf: RestconfRoot{YangLibraryVersion: YangLibraryVersion}}
+}
+
+func NewYangLibraryVersion() YangLibraryVersionWrapped {
+	return YangLibraryVersionWrapped{YangLibraryVersion: YangLibraryVersion}
+}
@@ -1,19 +1,90 @@
 package openapi
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+	"strconv"
 	"time"
 )
 
+// The encodings of the notification messages of a subscription, identities of ietf-subscribed-notifications.
+const (
+	EncodeJSON = "encode-json"
+	EncodeXML  = "encode-xml"
+)
+
 type EstablishSubscriptionInput struct {
 	Input struct {
 		Encoding     string `json:"encoding"`
 		Subscription struct {
 			Subscription []Subscription `json:"subscription"`
 		} `json:"subscriptions"`
+		DatastoreXPathFilter   string          `json:"ietf-yang-push:datastore-xpath-filter"`
+		DatastoreSubtreeFilter json.RawMessage `json:"ietf-yang-push:datastore-subtree-filter"`
+		Periodic               *Periodic       `json:"ietf-yang-push:periodic"`
+		OnChange               *OnChange       `json:"ietf-yang-push:on-change"`
+		StopTime               string          `json:"stop-time"`
+		ReplayStartTime        string          `json:"replay-start-time"`
+	} `json:"ietf-subscribed-notifications:input"`
+}
+
+// ModifySubscriptionInput changes the filter, the period, the dampening period or the stop time of a subscription.
+type ModifySubscriptionInput struct {
+	Input struct {
+		ID                     uint32          `json:"id"`
+		DatastoreXPathFilter   string          `json:"ietf-yang-push:datastore-xpath-filter"`
+		DatastoreSubtreeFilter json.RawMessage `json:"ietf-yang-push:datastore-subtree-filter"`
+		Periodic               *Periodic       `json:"ietf-yang-push:periodic"`
+		OnChange               *OnChange       `json:"ietf-yang-push:on-change"`
+		StopTime               string          `json:"stop-time"`
 	} `json:"ietf-subscribed-notifications:input"`
 }
 
+// Periodic holds the options of a periodic subscription, whose period is given in centiseconds.
+type Periodic struct {
+	Period     uint32 `json:"period"`
+	AnchorTime string `json:"anchor-time,omitempty"`
+}
+
+// OnChange holds the options of an on-change subscription. The dampening period is given in centiseconds,
+// and the changes of the excluded types are not notified.
+type OnChange struct {
+	DampeningPeriod uint32   `json:"dampening-period,omitempty"`
+	SyncOnStart     *bool    `json:"sync-on-start,omitempty"`
+	ExcludedChange  []string `json:"excluded-change,omitempty"`
+}
+
+// SubscriptionState is the content of the notifications telling the changes of the state of a subscription.
+type SubscriptionState struct {
+	ID                     uint32          `json:"id"`
+	Reason                 string          `json:"reason,omitempty"`
+	StopTime               string          `json:"stop-time,omitempty"`
+	DatastoreXPathFilter   string          `json:"ietf-yang-push:datastore-xpath-filter,omitempty"`
+	DatastoreSubtreeFilter json.RawMessage `json:"ietf-yang-push:datastore-subtree-filter,omitempty"`
+	Periodic               *Periodic       `json:"ietf-yang-push:periodic,omitempty"`
+	OnChange               *OnChange       `json:"ietf-yang-push:on-change,omitempty"`
+}
+
+type SubscriptionStateChange string
+
+// Notifications of the state changes of subscriptions (RFC 8639 section 2.7).
+const (
+	SubscriptionStarted    SubscriptionStateChange = "subscription-started"
+	SubscriptionModified   SubscriptionStateChange = "subscription-modified"
+	SubscriptionCompleted  SubscriptionStateChange = "subscription-completed"
+	SubscriptionTerminated SubscriptionStateChange = "subscription-terminated"
+	SubscriptionSuspended  SubscriptionStateChange = "subscription-suspended"
+	SubscriptionResumed    SubscriptionStateChange = "subscription-resumed"
+	ReplayCompleted        SubscriptionStateChange = "replay-completed"
+)
+
+// Reasons of the termination and of the suspension of subscriptions.
+const (
+	ReasonNoSuchSubscription    = "ietf-subscribed-notifications:no-such-subscription"
+	ReasonInsufficientResources = "ietf-subscribed-notifications:insufficient-resources"
+)
+
 type Subscription struct {
 	Topic          Topic          `json:"topic"`
 	ObjectTypeInfo ObjectTypeInfo `json:"object-type-info"`
@@ -25,9 +96,11 @@ type EstablishSubscriptionOutputWrapped struct {
 
 type EstablishSubscriptionOutput struct {
 	ID uint32 `json:"id"`
-	//ReplayStartTimeRevision string `json:"replay-start-time-revision"`
+	// ReplayStartTimeRevision is the time of the oldest retained event, when the replay-start-time was earlier
+	ReplayStartTimeRevision string `json:"replay-start-time-revision,omitempty"`
 }
 
+// DeleteSubscriptionInput is the input of the "delete-subscription" and "kill-subscription" operations.
 type DeleteSubscriptionInput struct {
 	Input struct {
 		ID uint32 `json:"id"`
@@ -51,8 +124,6 @@ const (
 	ObjectTypeInfoEthTranService ObjectTypeInfo = "eth-tran-service"
 	ObjectTypeInfoServicePm      ObjectTypeInfo = "service-pm"
 
-	OperationCreate Operation = "create"
-	OperationDelete Operation = "delete"
 	OperationUpdate Operation = "update"
 )
 
@@ -65,6 +136,34 @@ func NoSuchSubscriptionError() RestconfError {
 	}
 }
 
+// FilterUnsupportedError tells that the filter of the subscription cannot be applied, the message giving the reason.
+func FilterUnsupportedError(message string) RestconfError {
+	return RestconfError{
+		ErrorType:    ErrorTypeApplication,
+		ErrorTag:     ErrorTagInvalidValue,
+		ErrorMessage: message,
+		ErrorAppTag:  "ietf-subscribed-notifications:filter-unsupported",
+	}
+}
+
+func PeriodUnsupportedError(message string) RestconfError {
+	return RestconfError{
+		ErrorType:    ErrorTypeApplication,
+		ErrorTag:     ErrorTagInvalidValue,
+		ErrorMessage: message,
+		ErrorAppTag:  "ietf-yang-push:period-unsupported",
+	}
+}
+
+func ReplayUnsupportedError(message string) RestconfError {
+	return RestconfError{
+		ErrorType:    ErrorTypeApplication,
+		ErrorTag:     ErrorTagInvalidValue,
+		ErrorMessage: message,
+		ErrorAppTag:  "ietf-subscribed-notifications:replay-unsupported",
+	}
+}
+
 func EncodingUnsupportedError() RestconfError {
 	return RestconfError{
 		ErrorType:    ErrorTypeApplication,
@@ -82,9 +181,35 @@ type RestconfNotification struct {
 	Notification RestconfNotificationBody `json:"ietf-restconf:notification"`
 }
 
+// xmlNotification is the notification in the namespace of the NETCONF notifications (RFC 5277),
+// as encoded in XML.
+type xmlNotification struct {
+	Notification RestconfNotificationBody `json:"notifications:notification"`
+}
+
+// MarshalYangXML encodes the notification in XML (RFC 8040 section 6.4),
+// its content being in the namespaces of the modules defining it.
+func (notification RestconfNotification) MarshalYangXML(namespaces yangxml.Namespaces) ([]byte, error) {
+	return yangxml.Marshal(xmlNotification{Notification: notification.Notification}, namespaces)
+}
+
 type RestconfNotificationBody struct {
-	EventTime        string           `json:"eventTime"`
-	PushChangeUpdate PushChangeUpdate `json:"ietf-yang-push:push-change-update"`
+	EventTime              string             `json:"eventTime"`
+	PushUpdate             *PushUpdate        `json:"ietf-yang-push:push-update,omitempty"`
+	PushChangeUpdate       *PushChangeUpdate  `json:"ietf-yang-push:push-change-update,omitempty"`
+	SubscriptionStarted    *SubscriptionState `json:"ietf-subscribed-notifications:subscription-started,omitempty"`
+	SubscriptionModified   *SubscriptionState `json:"ietf-subscribed-notifications:subscription-modified,omitempty"`
+	SubscriptionCompleted  *SubscriptionState `json:"ietf-subscribed-notifications:subscription-completed,omitempty"`
+	SubscriptionTerminated *SubscriptionState `json:"ietf-subscribed-notifications:subscription-terminated,omitempty"`
+	SubscriptionSuspended  *SubscriptionState `json:"ietf-subscribed-notifications:subscription-suspended,omitempty"`
+	SubscriptionResumed    *SubscriptionState `json:"ietf-subscribed-notifications:subscription-resumed,omitempty"`
+	ReplayCompleted        *SubscriptionState `json:"ietf-subscribed-notifications:replay-completed,omitempty"`
+}
+
+// PushUpdate holds the filtered content of the datastore sent by a periodic subscription.
+type PushUpdate struct {
+	SubscriptionID    uint32      `json:"subscription-id"`
+	DatastoreContents interface{} `json:"datastore-contents"`
 }
 
 type PushChangeUpdate struct {
@@ -93,37 +218,92 @@ type PushChangeUpdate struct {
 	DatastoreChanges interface{}   `json:"datastore-changes"`
 }
 
-type YangPatch struct {
-	YangPatch YangPatchBody `json:"ietf-yang-patch:yang-patch"`
-}
-
-type YangPatchBody struct {
-	PatchID string `json:"patch-id"`
-	//Comment string          `json:"comment"`
-	Edit []YangPatchEdit `json:"edit"`
+func eventTime() string {
+	return FormatEventTime(time.Now())
 }
 
-type YangPatchEdit struct {
-	EditID    string      `json:"edit-id"`
-	Operation Operation   `json:"operation"`
-	Target    string      `json:"target"`
-	Value     interface{} `json:"value"`
+// FormatEventTime formats the time of an event, as the eventTime of a notification.
+func FormatEventTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
 }
 
-func NewRestconfNotification(id uint32, operation Operation, target string, value interface{}) RestconfNotification {
-	currentTime := time.Now()
+// NewRestconfNotification builds the push-change-update notification of a subscription, numbering its edits.
+func NewRestconfNotification(id uint32, edits ...YangPatchEdit) RestconfNotification {
+	for i := range edits {
+		edits[i].EditID = strconv.Itoa(i)
+	}
 	return RestconfNotification{
 		Notification: RestconfNotificationBody{
-			EventTime: currentTime.UTC().Format("2006-01-02T15:04:05.000Z"),
-			PushChangeUpdate: PushChangeUpdate{
+			EventTime: eventTime(),
+			PushChangeUpdate: &PushChangeUpdate{
 				SubscriptionID: id,
 				DatastoreChanges: YangPatch{
 					YangPatch: YangPatchBody{
 						PatchID: uuid.New().String(),
-						Edit:    []YangPatchEdit{{EditID: "0", Operation: operation, Target: target, Value: value}},
+						Edit:    edits,
 					},
 				},
 			},
 		},
 	}
 }
+
+// NewUpdatesNotSentNotification builds the push-change-update notification telling that updates of a subscription
+// were lost, holding no edit.
+func NewUpdatesNotSentNotification(id uint32) RestconfNotification {
+	notification := NewRestconfNotification(id, []YangPatchEdit{}...)
+	notification.Notification.PushChangeUpdate.UpdatesNotSent = []interface{}{nil}
+	return notification
+}
+
+// NewPushUpdateNotification builds the push-update notification of a subscription holding the datastore contents.
+func NewPushUpdateNotification(id uint32, contents interface{}) RestconfNotification {
+	return RestconfNotification{
+		Notification: RestconfNotificationBody{
+			EventTime:  eventTime(),
+			PushUpdate: &PushUpdate{SubscriptionID: id, DatastoreContents: contents},
+		},
+	}
+}
+
+// NewSubscriptionStateNotification builds the notification of a change of the state of a subscription.
+func NewSubscriptionStateNotification(change SubscriptionStateChange, state SubscriptionState) RestconfNotification {
+	body := RestconfNotificationBody{EventTime: eventTime()}
+	switch change {
+	case SubscriptionStarted:
+		body.SubscriptionStarted = &state
+	case SubscriptionModified:
+		body.SubscriptionModified = &state
+	case SubscriptionCompleted:
+		body.SubscriptionCompleted = &state
+	case SubscriptionTerminated:
+		body.SubscriptionTerminated = &state
+	case SubscriptionSuspended:
+		body.SubscriptionSuspended = &state
+	case SubscriptionResumed:
+		body.SubscriptionResumed = &state
+	case ReplayCompleted:
+		body.ReplayCompleted = &state
+	}
+	return RestconfNotification{Notification: body}
+}
+
+// SubscriptionID returns the identifier of the subscription the notification is sent to.
+func (notification RestconfNotification) SubscriptionID() uint32 {
+	body := notification.Notification
+	switch {
+	case body.PushUpdate != nil:
+		return body.PushUpdate.SubscriptionID
+	case body.PushChangeUpdate != nil:
+		return body.PushChangeUpdate.SubscriptionID
+	}
+	for _, state := range []*SubscriptionState{
+		body.SubscriptionStarted, body.SubscriptionModified, body.SubscriptionCompleted,
+		body.SubscriptionTerminated, body.SubscriptionSuspended, body.SubscriptionResumed, body.ReplayCompleted,
+	} {
+		if state != nil {
+			return state.ID
+		}
+	}
+	return 0
+}
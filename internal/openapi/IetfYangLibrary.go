@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+const (
+	DatastoreRunning     = "ietf-datastores:running"
+	DatastoreCandidate   = "ietf-datastores:candidate"
+	DatastoreIntended    = "ietf-datastores:intended"
+	DatastoreOperational = "ietf-datastores:operational"
+)
+
+const (
+	yangLibraryModuleSet = "mock-modules"
+	yangLibrarySchema    = "mock-schema"
+)
+
+// YangLibraryWrapped is the "yang-library" container of the ietf-yang-library module (RFC 8525).
+type YangLibraryWrapped struct {
+	YangLibrary YangLibrary `json:"ietf-yang-library:yang-library"`
+}
+
+type YangLibrary struct {
+	ModuleSet []YangLibraryModuleSet `json:"module-set"`
+	Schema    []YangLibrarySchema    `json:"schema"`
+	Datastore []YangLibraryDatastore `json:"datastore"`
+	ContentID string                 `json:"content-id"`
+}
+
+type YangLibraryModuleSet struct {
+	Name   string              `json:"name"`
+	Module []YangLibraryModule `json:"module"`
+}
+
+type YangLibraryModule struct {
+	Name      string `json:"name"`
+	Revision  string `json:"revision,omitempty"`
+	Namespace string `json:"namespace"`
+}
+
+type YangLibrarySchema struct {
+	Name      string   `json:"name"`
+	ModuleSet []string `json:"module-set"`
+}
+
+type YangLibraryDatastore struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// NewYangLibrary describes a single schema made of the modules, shared by all the datastores.
+// The content-id changes whenever the set of modules does.
+func NewYangLibrary(modules []YangLibraryModule) YangLibrary {
+	hash := sha1.New()
+	for _, module := range modules {
+		hash.Write([]byte(module.Name + "@" + module.Revision + ";"))
+	}
+	return YangLibrary{
+		ModuleSet: []YangLibraryModuleSet{{Name: yangLibraryModuleSet, Module: modules}},
+		Schema:    []YangLibrarySchema{{Name: yangLibrarySchema, ModuleSet: []string{yangLibraryModuleSet}}},
+		Datastore: []YangLibraryDatastore{
+			{Name: DatastoreRunning, Schema: yangLibrarySchema},
+			{Name: DatastoreCandidate, Schema: yangLibrarySchema},
+			{Name: DatastoreIntended, Schema: yangLibrarySchema},
+			{Name: DatastoreOperational, Schema: yangLibrarySchema},
+		},
+		ContentID: hex.EncodeToString(hash.Sum(nil)),
+	}
+}
+
+func (library YangLibrary) Wrap() YangLibraryWrapped {
+	return YangLibraryWrapped{YangLibrary: library}
+}
@@ -0,0 +1,76 @@
+package openapi
+
+const (
+	OperationCreate  Operation = "create"
+	OperationDelete  Operation = "delete"
+	OperationInsert  Operation = "insert"
+	OperationMerge   Operation = "merge"
+	OperationMove    Operation = "move"
+	OperationReplace Operation = "replace"
+	OperationRemove  Operation = "remove"
+)
+
+type YangPatch struct {
+	YangPatch YangPatchBody `json:"ietf-yang-patch:yang-patch"`
+}
+
+type YangPatchBody struct {
+	PatchID string          `json:"patch-id"`
+	Comment string          `json:"comment,omitempty"`
+	Edit    []YangPatchEdit `json:"edit"`
+}
+
+type YangPatchEdit struct {
+	EditID    string      `json:"edit-id"`
+	Operation Operation   `json:"operation"`
+	Target    string      `json:"target"`
+	Point     string      `json:"point,omitempty"`
+	Where     string      `json:"where,omitempty"`
+	Value     interface{} `json:"value"`
+}
+
+type YangPatchStatusWrapped struct {
+	Status YangPatchStatus `json:"ietf-yang-patch:yang-patch-status"`
+}
+
+type YangPatchStatus struct {
+	PatchID    string               `json:"patch-id"`
+	Ok         []interface{}        `json:"ok,omitempty"`
+	Errors     *YangPatchErrors     `json:"errors,omitempty"`
+	EditStatus *YangPatchEditStatus `json:"edit-status,omitempty"`
+}
+
+type YangPatchEditStatus struct {
+	Edit []YangPatchEditStatusEntry `json:"edit"`
+}
+
+type YangPatchEditStatusEntry struct {
+	EditID string           `json:"edit-id"`
+	Ok     []interface{}    `json:"ok,omitempty"`
+	Errors *YangPatchErrors `json:"errors,omitempty"`
+}
+
+type YangPatchErrors struct {
+	Error []RestconfError `json:"error"`
+}
+
+// yangEmpty is the JSON encoding of a leaf of type empty.
+var yangEmpty = []interface{}{nil}
+
+func NewYangPatchOk(patchID string) YangPatchStatusWrapped {
+	return YangPatchStatusWrapped{Status: YangPatchStatus{PatchID: patchID, Ok: yangEmpty}}
+}
+
+func NewYangPatchGlobalError(patchID string, errors ...RestconfError) YangPatchStatusWrapped {
+	return YangPatchStatusWrapped{Status: YangPatchStatus{PatchID: patchID, Errors: &YangPatchErrors{Error: errors}}}
+}
+
+func NewYangPatchEditError(patchID string, editID string, errors ...RestconfError) YangPatchStatusWrapped {
+	return YangPatchStatusWrapped{Status: YangPatchStatus{
+		PatchID: patchID,
+		EditStatus: &YangPatchEditStatus{Edit: []YangPatchEditStatusEntry{{
+			EditID: editID,
+			Errors: &YangPatchErrors{Error: errors},
+		}}},
+	}}
+}
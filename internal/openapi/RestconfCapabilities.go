@@ -0,0 +1,73 @@
+package openapi
+
+// Capability URIs of the optional RESTCONF protocol features supported by the mock (RFC 8040 section 9.1.2).
+const (
+	CapabilityDefaults     = "urn:ietf:params:restconf:capability:defaults:1.0?basic-mode=explicit"
+	CapabilityDepth        = "urn:ietf:params:restconf:capability:depth:1.0"
+	CapabilityFields       = "urn:ietf:params:restconf:capability:fields:1.0"
+	CapabilityWithDefaults = "urn:ietf:params:restconf:capability:with-defaults:1.0"
+	CapabilityYangPatch    = "urn:ietf:params:restconf:capability:yang-patch:1.0"
+)
+
+type RestconfCapabilitiesWrapped struct {
+	Capabilities RestconfCapabilities `json:"ietf-restconf-monitoring:capabilities"`
+}
+
+type RestconfCapabilities struct {
+	Capability []string `json:"capability"`
+}
+
+func NewRestconfCapabilities() RestconfCapabilities {
+	return RestconfCapabilities{
+		Capability: []string{
+			CapabilityDefaults,
+			CapabilityDepth,
+			CapabilityFields,
+			CapabilityWithDefaults,
+			CapabilityYangPatch,
+		},
+	}
+}
+
+func (capabilities RestconfCapabilities) Wrap() RestconfCapabilitiesWrapped {
+	return RestconfCapabilitiesWrapped{Capabilities: capabilities}
+}
+
+// RestconfStateWrapped is the "restconf-state" container of the ietf-restconf-monitoring module (RFC 8040 section 9).
+type RestconfStateWrapped struct {
+	RestconfState RestconfState `json:"ietf-restconf-monitoring:restconf-state"`
+}
+
+type RestconfState struct {
+	Capabilities RestconfCapabilities `json:"capabilities"`
+	Streams      RestconfStreams      `json:"streams"`
+}
+
+type RestconfStreamsWrapped struct {
+	Streams RestconfStreams `json:"ietf-restconf-monitoring:streams"`
+}
+
+type RestconfStreams struct {
+	Stream []RestconfStream `json:"stream"`
+}
+
+type RestconfStream struct {
+	Name                  string                 `json:"name"`
+	Description           string                 `json:"description,omitempty"`
+	ReplaySupport         bool                   `json:"replay-support,omitempty"`
+	ReplayLogCreationTime string                 `json:"replay-log-creation-time,omitempty"`
+	Access                []RestconfStreamAccess `json:"access"`
+}
+
+type RestconfStreamAccess struct {
+	Encoding string `json:"encoding"`
+	Location string `json:"location"`
+}
+
+func (state RestconfState) Wrap() RestconfStateWrapped {
+	return RestconfStateWrapped{RestconfState: state}
+}
+
+func (streams RestconfStreams) Wrap() RestconfStreamsWrapped {
+	return RestconfStreamsWrapped{Streams: streams}
+}
@@ -73,6 +73,13 @@ const (
 	ErrorTagDataExists           = "data-exists"
 	ErrorTagBadElement           = "bad-element"
 	ErrorTagResourceDenied       = "resource-denied"
+	ErrorTagMissingAttribute     = "missing-attribute"
+	ErrorTagBadAttribute         = "bad-attribute"
+	ErrorTagDataMissing          = "data-missing"
+	ErrorTagMalformedMessage     = "malformed-message"
+	ErrorTagLockDenied           = "lock-denied"
+	ErrorTagInUse                = "in-use"
+	ErrorTagMissingElement       = "missing-element"
 )
 
 func NewRestconfErrors(errors ...RestconfError) RestconfErrors {
@@ -81,6 +88,41 @@ func NewRestconfErrors(errors ...RestconfError) RestconfErrors {
 	}
 }
 
+func InvalidQueryParameterError(name string, value string) RestconfError {
+	return RestconfError{
+		ErrorType:    ErrorTypeProtocol,
+		ErrorTag:     ErrorTagInvalidValue,
+		ErrorMessage: "Invalid value '" + value + "' for query parameter '" + name + "'",
+	}
+}
+
+func MissingAttributeError(name string, message string) RestconfError {
+	return RestconfError{
+		ErrorType:    ErrorTypeProtocol,
+		ErrorTag:     ErrorTagMissingAttribute,
+		ErrorMessage: message,
+		ErrorInfo:    map[string]string{"bad-attribute": name},
+	}
+}
+
+func BadAttributeError(name string, message string) RestconfError {
+	return RestconfError{
+		ErrorType:    ErrorTypeProtocol,
+		ErrorTag:     ErrorTagBadAttribute,
+		ErrorMessage: message,
+		ErrorInfo:    map[string]string{"bad-attribute": name},
+	}
+}
+
+func InvalidValueError(path string, message string) RestconfError {
+	return RestconfError{
+		ErrorType:    ErrorTypeApplication,
+		ErrorTag:     ErrorTagInvalidValue,
+		ErrorPath:    path,
+		ErrorMessage: message,
+	}
+}
+
 func (r RestconfErrors) Error() string {
 	marshal, _ := json.Marshal(r)
 	return string(marshal)
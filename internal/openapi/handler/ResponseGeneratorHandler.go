@@ -3,7 +3,6 @@ package handler
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/exgphe/kin-openapi/openapi3"
 	"github.com/exgphe/kin-openapi/routers"
 	"github.com/exgphe/kin-openapi/routers/legacy"
@@ -12,18 +11,18 @@ import (
 	"github.com/muonsoft/openapi-mock/internal/openapi/generator"
 	"github.com/muonsoft/openapi-mock/internal/openapi/responder"
 	sc "github.com/muonsoft/openapi-mock/internal/openapi/subscriptionCenter"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
 	"github.com/muonsoft/openapi-mock/openapi-validator"
 	"github.com/muonsoft/openapi-mock/pkg/logcontext"
 	"github.com/pkg/errors"
 	"github.com/spyzhov/ajson"
-	"github.com/yudai/gojsondiff"
 	"google.golang.org/grpc"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var subscriptionCenter = sc.NewSubscriptionCenter()
@@ -35,6 +34,12 @@ type responseGeneratorHandler struct {
 	databasePath      string
 	grpcPort          uint16
 	sseInterval       uint64
+	xmlNamespaces     yangxml.Namespaces
+	modules           []openapi.YangLibraryModule
+	propagator        *propagator
+	netconf           netconfState
+	operations        map[string]OperationResponse
+	suppressEcho      bool
 }
 
 func NewResponseGeneratorHandler(
@@ -44,6 +49,12 @@ func NewResponseGeneratorHandler(
 	databasePath string,
 	grpcPort uint16,
 	sseInterval uint64,
+	xmlNamespaces yangxml.Namespaces,
+	propagationDelay time.Duration,
+	operations map[string]OperationResponse,
+	suppressEcho bool,
+	eventLogSize uint64,
+	eventLogPath string,
 ) http.Handler {
 	generatorHandler := &responseGeneratorHandler{
 		router:            router,
@@ -52,11 +63,30 @@ func NewResponseGeneratorHandler(
 		databasePath:      databasePath,
 		grpcPort:          grpcPort,
 		sseInterval:       sseInterval,
+		xmlNamespaces:     xmlNamespaces,
+		modules:           specModules(router.Doc, xmlNamespaces),
+		propagator:        newPropagator(databasePath, router.Doc, propagationDelay),
+		operations:        operations,
+		suppressEcho:      suppressEcho,
 	}
-
-	return &optionsHandler{
-		router:      router,
-		nextHandler: generatorHandler,
+	subscriptionCenter.SetEventLog(int(eventLogSize), eventLogPath)
+	subscriptionCenter.SetXMLNamespaces(xmlNamespaces)
+	subscriptionCenter.SetDatastore(func() (*ajson.Node, error) {
+		running, err := generatorHandler.loadDatastore(openapi.DatastoreRunning)
+		if err != nil {
+			return nil, err
+		}
+		return running.Content, nil
+	}, generatorHandler.propagator.schema)
+
+	return &discoveryHandler{
+		responder: responder,
+		nextHandler: &datastoreHandler{
+			nextHandler: &optionsHandler{
+				router:      router,
+				nextHandler: generatorHandler,
+			},
+		},
 	}
 }
 
@@ -81,246 +111,19 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 			handler.notFound(writer, request)
 			return
 		}
-		differ := gojsondiff.New()
-		result, err := differ.Compare(previousDatabaseData, afterDatabaseData)
+		previousDatabase, err := ajson.Unmarshal(previousDatabaseData)
 		if err != nil {
-			handler.responder.WriteError(ctx, writer, request.URL.Path, err)
+			handler.internalError(ctx, writer, request, err)
 			return
 		}
-		if result.Modified() {
-			logger.Infoln("检测到改动")
-			//	// TODO detailed diffs
-			previousDatabase, err := ajson.Unmarshal(previousDatabaseData)
-			if err != nil {
-				handler.responder.WriteError(ctx, writer, request.URL.Path, err)
-				return
-			}
-			afterDatabase, err := ajson.Unmarshal(afterDatabaseData)
-			if err != nil {
-				handler.responder.WriteError(ctx, writer, request.URL.Path, err)
-				return
-			}
-			previousNetworksNode, err := previousDatabase.JSONPath("$['ietf-network:networks'].network")
-			if err != nil {
-				handler.responder.WriteError(ctx, writer, request.URL.Path, err)
-				return
-			}
-			previousNetworks, _ := previousNetworksNode[0].GetArray()
-			for _, previousNetwork := range previousNetworks {
-				networkID, _ := previousNetwork.GetKey("network-id")
-				networkIDString, _ := networkID.GetString()
-				afterNetworkCandidates, _ := afterDatabase.JSONPath("$['ietf-network:networks'].network[?(@['network-id']=='" + networkIDString + "')]")
-				if len(afterNetworkCandidates) > 0 {
-					afterNetwork := afterNetworkCandidates[0]
-					// Compare Nodes
-					previousNodesNode, _ := previousNetwork.GetKey("node")
-					previousNodes, _ := previousNodesNode.GetArray()
-					previousNodesIds := make(map[string]bool)
-					for _, previousNode := range previousNodes {
-						nodeID, _ := previousNode.GetKey("node-id")
-						nodeIDString, _ := nodeID.GetString()
-						previousNodesIds[nodeIDString] = true
-						afterNodeArray, _ := afterNetwork.JSONPath("@.node[?(@['node-id']=='" + nodeIDString + "')]")
-						if len(afterNodeArray) > 0 {
-							afterNode := afterNodeArray[0]
-							// Compare tp
-							previousTpsNode, _ := previousNode.GetKey("ietf-network-topology:termination-point")
-							previousTps, _ := previousTpsNode.GetArray()
-							previousTpsIds := make(map[string]bool)
-							for _, previousTp := range previousTps {
-								tpID, _ := previousTp.GetKey("tp-id")
-								tpIDString, _ := tpID.GetString()
-								previousTpsIds[tpIDString] = true
-								afterTpArray, _ := afterNode.JSONPath("@['ietf-network-topology:termination-point'][?(@['tp-id']=='" + tpIDString + "')]")
-								if len(afterTpArray) > 0 {
-									afterTp := afterTpArray[0]
-									eq, _ := previousTp.Eq(afterTp)
-									if err != nil {
-										logger.Errorf("tp compare error", err)
-									} else {
-										if !eq {
-											value, _ := afterTp.Unpack()
-											err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoTP, openapi.OperationUpdate, value, networkIDString, nodeIDString, tpIDString)
-											if err != nil {
-												logger.Errorf("tp update notification error", err)
-												break
-											}
-										}
-									}
-								} else {
-									err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoTP, openapi.OperationDelete, nil, networkIDString, nodeIDString, tpIDString)
-									if err != nil {
-										logger.Errorf("tp delete notification error", err)
-										break
-									}
-								}
-							}
-
-							afterTpsNode, _ := afterNode.GetKey("ietf-network-topology:termination-point")
-							afterTps, _ := afterTpsNode.GetArray()
-							for _, afterTp := range afterTps {
-								tpID, _ := afterTp.GetKey("tp-id")
-								tpIDString, _ := tpID.GetString()
-								if !previousTpsIds[tpIDString] {
-									value, _ := afterTp.Unpack()
-									err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoTP, openapi.OperationCreate, value, networkIDString, nodeIDString, tpIDString)
-									if err != nil {
-										logger.Errorf("tp added notification error", err)
-										break
-									}
-								}
-							}
-							// TODO compare ttp
-							previousTtps, _ := previousNode.JSONPath("@['ietf-te-topology:te']['tunnel-termination-point'][*]")
-							previousTtpsIds := make(map[string]bool)
-							for _, previousTtp := range previousTtps {
-								ttpID, _ := previousTtp.GetKey("tunnel-tp-id")
-								ttpIDString, _ := ttpID.GetString()
-								previousTtpsIds[ttpIDString] = true
-								afterTtpArray, _ := afterNode.JSONPath("@['ietf-te-topology:te']['tunnel-termination-point'][?(@['tunnel-tp-id']=='" + ttpIDString + "')]")
-								if len(afterTtpArray) > 0 {
-									afterTtp := afterTtpArray[0]
-									eq, _ := previousTtp.Eq(afterTtp)
-									if err != nil {
-										logger.Errorf("ttp compare error", err)
-									} else {
-										if !eq {
-											value, _ := afterTtp.Unpack()
-											err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoTTP, openapi.OperationUpdate, value, networkIDString, nodeIDString, ttpIDString)
-											if err != nil {
-												logger.Errorf("ttp update notification error", err)
-												break
-											}
-										}
-									}
-								} else {
-									err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoTTP, openapi.OperationDelete, nil, networkIDString, nodeIDString, ttpIDString)
-									if err != nil {
-										logger.Errorf("ttp delete notification error", err)
-										break
-									}
-								}
-							}
-
-							afterTtps, _ := afterNode.JSONPath("@['ietf-te-topology:te']['tunnel-termination-point'][*]")
-							for _, afterTtp := range afterTtps {
-								ttpID, _ := afterTtp.GetKey("tunnel-tp-id")
-								ttpIDString, _ := ttpID.GetString()
-								if !previousTtpsIds[ttpIDString] {
-									value, _ := afterTtp.Unpack()
-									err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoTTP, openapi.OperationCreate, value, networkIDString, nodeIDString, ttpIDString)
-									if err != nil {
-										logger.Errorf("ttp added notification error", err)
-										break
-									}
-								}
-							}
-
-							// Remove tp and ttp
-							previousTe, _ := previousNode.GetKey("ietf-te-topology:te")
-							previousTtpsNode, _ := previousTe.GetKey("tunnel-termination-point")
-							afterTe, _ := afterNode.GetKey("ietf-te-topology:te")
-							afterTtpsNode, _ := afterTe.GetKey("tunnel-termination-point")
-
-							// check updated
-							_ = previousNode.DeleteKey("ietf-network-topology:termination-point")
-							_ = afterNode.DeleteKey("ietf-network-topology:termination-point")
-							_ = previousTtpsNode.Delete()
-							_ = afterTtpsNode.Delete()
-
-							eq, _ := previousNode.Eq(afterNode)
-							if err != nil {
-								logger.Errorf("node compare error", err)
-							} else {
-								if !eq {
-									value, _ := afterNode.Unpack()
-									err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoNode, openapi.OperationUpdate, value, networkIDString, nodeIDString)
-									if err != nil {
-										logger.Errorf("node update notification error", err)
-										break
-									}
-								}
-							}
-						} else {
-							err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoNode, openapi.OperationDelete, nil, networkIDString, nodeIDString)
-							if err != nil {
-								logger.Errorf("node delete notification error", err)
-								break
-							}
-						}
-					}
-					// check added
-					afterNodesNode, _ := afterNetwork.GetKey("node")
-					afterNodes, _ := afterNodesNode.GetArray()
-					for _, afterNode := range afterNodes {
-						nodeID, _ := afterNode.GetKey("node-id")
-						nodeIDString, _ := nodeID.GetString()
-						if !previousNodesIds[nodeIDString] {
-							value, _ := afterNode.Unpack()
-							err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoNode, openapi.OperationCreate, value, networkIDString, nodeIDString)
-							if err != nil {
-								logger.Errorf("node added notification error", err)
-								break
-							}
-						}
-					}
-					// Compare Links
-					previousLinksNode, _ := previousNetwork.GetKey("ietf-network-topology:link")
-					previousLinks, _ := previousLinksNode.GetArray()
-					previousLinksIds := make(map[string]bool)
-					for _, previousLink := range previousLinks {
-						linkID, _ := previousLink.GetKey("link-id")
-						linkIDString, _ := linkID.GetString()
-						previousLinksIds[linkIDString] = true
-						afterLinkArray, _ := afterNetwork.JSONPath("@['ietf-network-topology:link'][?(@['link-id']=='" + linkIDString + "')]")
-						if len(afterLinkArray) > 0 {
-							afterLink := afterLinkArray[0]
-							// check updated
-							eq, _ := previousLink.Eq(afterLink)
-							if err != nil {
-								logger.Errorf("link compare error", err)
-							} else {
-								if !eq {
-									value, _ := afterLink.Unpack()
-									err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoLink, openapi.OperationUpdate, value, networkIDString, linkIDString)
-									if err != nil {
-										logger.Errorf("link update notification error", err)
-										break
-									}
-								}
-							}
-						} else {
-							err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoLink, openapi.OperationDelete, nil, networkIDString, linkIDString)
-							if err != nil {
-								logger.Errorf("link delete notification error", err)
-								break
-							}
-						}
-					}
-					// check added
-					afterLinksNode, _ := afterNetwork.GetKey("ietf-network-topology:link")
-					afterLinks, _ := afterLinksNode.GetArray()
-					for _, afterLink := range afterLinks {
-						linkID, _ := afterLink.GetKey("link-id")
-						linkIDString, _ := linkID.GetString()
-						if !previousLinksIds[linkIDString] {
-							value, _ := afterLink.Unpack()
-							err := subscriptionCenter.SendAll(openapi.ObjectTypeInfoLink, openapi.OperationCreate, value, networkIDString, linkIDString)
-							if err != nil {
-								logger.Errorf("node added notification error", err)
-								break
-							}
-						}
-					}
-				}
-			}
-			//err = subscriptionCenter.SendAll(openapi.ObjectTypeInfoNode, openapi.OperationUpdate, nil, networkID)
-			//if err != nil {
-			//	handler.responder.WriteError(ctx, writer, request.URL.Path, err)
-			//	return
-			//}
-		} else {
-			logger.Infoln("未检测到改动")
+		afterDatabase, err := ajson.Unmarshal(afterDatabaseData)
+		if err != nil {
+			handler.internalError(ctx, writer, request, err)
+			return
+		}
+		err = subscriptionCenter.Publish(previousDatabase, afterDatabase, "")
+		if err != nil {
+			logger.Errorf("Failed to notify the changes: %v", err)
 		}
 		handler.responder.WriteResponse(ctx, writer, request.URL.Path, &generator.Response{
 			StatusCode:  http.StatusNoContent,
@@ -328,17 +131,20 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 			Data:        nil,
 		})
 		return
-	} else if strings.HasPrefix(request.URL.Path, "/restconf/streams/yang-push-json/subscription-id=") {
-		id, err := strconv.Atoi(request.URL.Path[49:])
+	} else if isSubscriptionStatePath(request.URL.Path) {
+		handler.serveSubscriptionState(writer, request)
+		return
+	} else if encoding, streamID, isStream := subscriptionStream(request.URL.Path); isStream {
+		id, err := strconv.Atoi(streamID)
 		if err != nil {
-			handler.responder.WriteError(ctx, writer, request.URL.Path, err)
+			handler.internalError(ctx, writer, request, err)
 			return
 		}
-		subscriptions := subscriptionCenter.Get(uint32(id))
-		if subscriptions != nil {
+		// the notifications of a subscription are streamed in its encoding only
+		if subscriptionCenter.Encoding(uint32(id)) == encoding {
 			err = subscriptionCenter.Connect(uint32(id), handler.sseInterval, writer, request)
 			if err != nil {
-				handler.responder.WriteError(ctx, writer, request.URL.Path, err)
+				handler.internalError(ctx, writer, request, err)
 				return
 			}
 		} else {
@@ -346,6 +152,48 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 			return
 		}
 		return
+	} else if isStateResource(request.URL.Path) {
+		if datastore, ok := requestDatastore(request); ok && datastore.configOnly() {
+			// the state resources are part of the operational datastore only
+			handler.notFound(writer, request)
+			return
+		}
+		query, restconfError := parseRestconfQuery(request)
+		if restconfError != nil {
+			restconfError.ErrorPath = request.URL.Path
+			handler.badRequestRestconf(writer, request, *restconfError)
+			return
+		}
+		// state resources hold no configuration, and no default values
+		query.content = database.ContentAll
+		query.withDefaults = database.WithDefaultsExplicit
+		data, err := applyRetrievalQuery(handler.stateResource(request), query, nil)
+		if err != nil {
+			handler.internalError(ctx, writer, request, err)
+			return
+		}
+		handler.responder.WriteResponse(ctx, writer, request.URL.Path, &generator.Response{
+			StatusCode:  http.StatusOK,
+			ContentType: negotiatedContentType(request, yangDataJSON),
+			Data:        data,
+		})
+		return
+	} else if isDatastoreRoot(request) {
+		datastore, _ := requestDatastore(request)
+		query, restconfError := parseRestconfQuery(request)
+		if restconfError != nil {
+			restconfError.ErrorPath = request.URL.Path
+			handler.badRequestRestconf(writer, request, *restconfError)
+			return
+		}
+		if datastore.configOnly() {
+			query.content = database.ContentConfig
+		}
+		handler.serveDatastoreRoot(writer, request, datastore, query)
+		return
+	} else if isNetconfOperation(request.URL.Path) {
+		handler.serveNetconfOperation(writer, request)
+		return
 	}
 
 	route, rawPathParameters, aErr := (*handler.router).FindRoute(request)
@@ -353,7 +201,7 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 	for key, value := range rawPathParameters {
 		unescape, err := url.PathUnescape(value)
 		if err != nil {
-			handler.responder.WriteError(ctx, writer, request.URL.Path, err)
+			handler.internalError(ctx, writer, request, err)
 			return
 		}
 		pathParameters[key] = unescape
@@ -364,6 +212,16 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 		logger.Debugf("Route '%s %s' was not found", request.Method, request.URL)
 		return
 	}
+	query, restconfError := parseRestconfQuery(request)
+	if restconfError != nil {
+		restconfError.ErrorPath = request.URL.Path
+		handler.badRequestRestconf(writer, request, *restconfError)
+		return
+	}
+	datastore, isDatastore := requestDatastore(request)
+	if isDatastore && datastore.configOnly() {
+		query.content = database.ContentConfig
+	}
 	var bodyData []byte
 	var err error
 	if request.Body != http.NoBody && request.Body != nil {
@@ -374,6 +232,14 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 		}
 		defer request.Body.Close()
 	}
+	xmlBody := len(bodyData) > 0 && isXMLMediaType(request.Header.Get("Content-Type"))
+	if xmlBody {
+		bodyData, err = handler.decodeXMLBody(bodyData, route)
+		if err != nil {
+			handler.malformedMessage(writer, request, err)
+			return
+		}
+	}
 
 	//routingValidation := &openapi3filter.RequestValidationInput{
 	//	Request:    request,
@@ -388,7 +254,7 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 
 	conn, err := grpc.Dial("localhost:"+strconv.Itoa(int(handler.grpcPort)), grpc.WithInsecure()) // TODO don't hard code
 	if err != nil {
-		handler.responder.WriteError(ctx, writer, request.URL.Path, errors.New("Validation Server Down"))
+		handler.internalError(ctx, writer, request, errors.New("Validation Server Down"))
 		return
 	}
 	defer conn.Close()
@@ -399,22 +265,31 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 	for key, values := range request.Header {
 		headers[strings.ToLower(key)] = strings.Join(values, ", ")
 	}
+	if xmlBody {
+		// the body has been converted into its JSON encoding
+		headers["content-type"] = yangDataJSON
+	}
 	queries := map[string]string{}
 	for key, values := range request.URL.Query() {
 		queries[key] = values[0]
 	}
 
+	validatedBody := bodyData
+	if isYangPatch(request) {
+		// the edits of a YANG Patch are checked against the schema when they are resolved
+		validatedBody = nil
+	}
 	validationResponse, err := validationService.Validate(ctx, &openapi_validator.ValidationRequest{
 		Path:               route.Path,
 		Method:             request.Method,
 		Headers:            headers,
 		Params:             pathParameters,
 		Query:              queries,
-		Body:               bodyData,
+		Body:               validatedBody,
 		ValidatingResponse: false,
 	})
 	if err != nil {
-		handler.responder.WriteError(ctx, writer, request.URL.Path, errors.WithMessage(err, "Validation Service Error"))
+		handler.internalError(ctx, writer, request, errors.WithMessage(err, "Validation Service Error"))
 		logger.Errorf("Validation Service Error", err)
 		return
 	}
@@ -426,48 +301,102 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 		return
 	}
 	var operation = route.Operation
+	invokesOperation := isOperationInvocation(request, route)
 
 	response, err := handler.responseGenerator.GenerateResponse(request, route)
 	if err != nil {
-		handler.responder.WriteError(ctx, writer, request.URL.Path, err)
+		handler.internalError(ctx, writer, request, err)
 		return
 	}
 
-	filename := handler.databasePath
-	db, err := database.Load(filename)
+	target := openapi.DatastoreRunning
+	if isDatastore {
+		target = datastore.name
+	}
+	if isWrite(request) && !invokesOperation && !handler.checkLock(writer, request, target) {
+		return
+	}
+	if isDatastore && datastore.readOnly() {
+		err = handler.propagator.ensure()
+		if err != nil {
+			handler.internalError(ctx, writer, request, err)
+			return
+		}
+	}
+	filename := handler.propagator.filename(target)
+	db, err := handler.loadDatastore(target)
 	if err != nil {
 		logger.Errorf("Json read error", err)
 		db = database.NewDatabase()
 	}
+	editsRunning := isWrite(request) && !invokesOperation && target == openapi.DatastoreRunning
+	var previous *database.Database
+	if editsRunning {
+		previous, err = db.Copy()
+		if err != nil {
+			handler.internalError(ctx, writer, request, err)
+			return
+		}
+	}
 
+	// the read-only datastores are written by the propagator only, under its lock,
+	// so that the propagated content is not overwritten by the one loaded for the request
+	savesDatastore := !isDatastore || !datastore.readOnly()
 	defer func() {
-		err = db.Save(filename)
-		if err != nil {
-			logger.Errorf("Save requests error", err)
+		if savesDatastore {
+			err = db.Save(filename)
+			if err != nil {
+				logger.Errorf("Save requests error", err)
+			}
+		}
+		if editsRunning {
+			handler.propagate(request, db)
+			handler.publishChanges(request, previous, db)
 		}
 	}()
 
 	keyPath, err := database.RestconfPathToKeyPath(request.URL.Path, operation)
 	if err != nil {
-		handler.responder.WriteError(ctx, writer, request.URL.Path, errors.WithMessage(err, "Keypath Convert Error"))
+		handler.internalError(ctx, writer, request, errors.WithMessage(err, "Keypath Convert Error"))
 		logger.Errorf("Keypath convert error", err)
 		return
 	}
 
-	if strings.Contains(request.URL.Path, "restconf/operations/") {
+	if !invokesOperation {
+		metadata, err := db.Metadata(keyPath)
+		if err != nil {
+			handler.internalError(ctx, writer, request, err)
+			return
+		}
+		_, _, err = db.Get(keyPath)
+		if statusCode := evaluatePreconditions(request, metadata, err == nil); statusCode != 0 {
+			handler.writePreconditionResult(writer, request, statusCode, metadata)
+			return
+		}
+	}
+
+	if invokesOperation {
+		invocation, err := newOperationInvocation(request, route, bodyData, db)
+		if err != nil {
+			handler.badRequest(writer, request, errors.WithMessage(err, "Cannot extract body"))
+			logger.Errorf("Cannot extract body", err)
+			return
+		}
+		if invocation.instance != "" && invocation.instanceData == nil {
+			// actions are invoked on existing data nodes only
+			handler.notFound(writer, request)
+			return
+		}
 		switch request.URL.Path {
 		case "/restconf/operations/ietf-subscribed-notifications:establish-subscription":
 			var requestInput openapi.EstablishSubscriptionInput
 			err := json.Unmarshal(bodyData, &requestInput)
 			if err == nil {
-				if requestInput.Input.Encoding != "" && requestInput.Input.Encoding != "ietf-subscribed-notifications:encode-json" && requestInput.Input.Encoding != "encode-json" {
-					handler.badRequestRestconf(writer, request, openapi.EncodingUnsupportedError())
+				output, restconfError := subscriptionCenter.Subscribe(requestInput, clientIdentity(request))
+				if restconfError != nil {
+					handler.badRequestRestconf(writer, request, *restconfError)
 					return
 				}
-				id := subscriptionCenter.Subscribe(requestInput.Input.Subscription.Subscription)
-				output := openapi.EstablishSubscriptionOutput{
-					ID: id,
-				}
 				response.Data = output.Wrap()
 			} else {
 				handler.badRequest(writer, request, errors.WithMessage(err, "Cannot extract body"))
@@ -479,7 +408,7 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 			err := json.Unmarshal(bodyData, &requestInput)
 			if err == nil {
 				id := requestInput.Input.ID
-				success := subscriptionCenter.Delete(id)
+				success := subscriptionCenter.Unsubscribe(id, clientIdentity(request))
 				if !success {
 					handler.badRequestRestconf(writer, request, openapi.NoSuchSubscriptionError())
 					return
@@ -491,51 +420,41 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 				logger.Errorf("Cannot extract body", err)
 				return
 			}
-		case "/restconf/operations/ietf-te:globals-rpc":
-			response.StatusCode = http.StatusNoContent
-			response.Data = nil
-		case "/restconf/operations/ietf-te:interfaces-rpc":
-			response.StatusCode = http.StatusNoContent
-			response.Data = nil
-		case "/restconf/operations/ietf-te:tunnels-rpc":
-			jsonFile, err := os.Open("/Users/exgphe/ietf-te-tunnels-rpc.json") // TODO
-			if err != nil {
-				fmt.Println("error opening json file")
-				return
-			}
-			defer jsonFile.Close()
-			response.StatusCode = http.StatusOK
-			jsonData, err := ioutil.ReadAll(jsonFile)
+		case "/restconf/operations/ietf-subscribed-notifications:modify-subscription":
+			var requestInput openapi.ModifySubscriptionInput
+			err := json.Unmarshal(bodyData, &requestInput)
 			if err != nil {
-				fmt.Println("error reading json file")
+				handler.badRequest(writer, request, errors.WithMessage(err, "Cannot extract body"))
+				logger.Errorf("Cannot extract body", err)
 				return
 			}
-			err = json.Unmarshal(jsonData, &response.Data)
-			if err != nil {
-				fmt.Println("error unmarshalling")
+			if restconfError := subscriptionCenter.Modify(requestInput, clientIdentity(request)); restconfError != nil {
+				handler.badRequestRestconf(writer, request, *restconfError)
 				return
 			}
-		case "/restconf/operations/ietf-wson-tunnel:wson-te-tunnel-path-compute":
-			jsonFile, err := os.Open("/Users/exgphe/ietf-wson-tunnel-wson-te-tunnel-path-compute.json") // TODO
+			response.StatusCode = http.StatusNoContent
+			response.Data = nil
+		case "/restconf/operations/ietf-subscribed-notifications:kill-subscription":
+			var requestInput openapi.DeleteSubscriptionInput
+			err := json.Unmarshal(bodyData, &requestInput)
 			if err != nil {
-				fmt.Println("error opening json file")
+				handler.badRequest(writer, request, errors.WithMessage(err, "Cannot extract body"))
+				logger.Errorf("Cannot extract body", err)
 				return
 			}
-			defer jsonFile.Close()
-			response.StatusCode = http.StatusOK
-			jsonData, err := ioutil.ReadAll(jsonFile)
-			if err != nil {
-				fmt.Println("error reading json file")
+			if !subscriptionCenter.Kill(requestInput.Input.ID) {
+				handler.badRequestRestconf(writer, request, openapi.NoSuchSubscriptionError())
 				return
 			}
-			err = json.Unmarshal(jsonData, &response.Data)
+			response.StatusCode = http.StatusNoContent
+			response.Data = nil
+		default:
+			err = handler.respondToOperation(request, route, invocation, db, response)
 			if err != nil {
-				fmt.Println("error unmarshalling")
+				handler.internalError(ctx, writer, request, err)
+				logger.Errorf("Operation response error", err)
 				return
 			}
-		default:
-			// TODO extract data of the url and put it in the request
-			break
 		}
 	} else if request.Method != "DELETE" {
 		// Try to read from database
@@ -562,6 +481,22 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 				logger.Debugf("Route '%s %s' was not found", request.Method, request.URL)
 				return
 			}
+			if request.Method == "GET" {
+				response.Data, err = applyRetrievalQuery(response.Data, query, responseDataSchema(route))
+				if err != nil {
+					handler.internalError(ctx, writer, request, err)
+					return
+				}
+			}
+		} else if isYangPatch(request) {
+			statusCode, status := applyYangPatch(request, db, keyPath, route, bodyData)
+			response.StatusCode = statusCode
+			response.ContentType = negotiatedContentType(request, yangDataJSON)
+			response.Data = status
+			if statusCode != http.StatusOK {
+				handler.responder.WriteResponse(ctx, writer, request.URL.Path, response)
+				return
+			}
 		} else if request.Method == "POST" || request.Method == "PUT" || request.Method == "PATCH" {
 			body, err := ajson.Unmarshal(bodyData)
 			if err == nil {
@@ -599,16 +534,19 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 								}
 							}
 						}
-						rawXKey, ok := topProperty.Value.Extensions["x-key"]
-						var xKey string
-						var listKeys []string
-						if ok {
-							err := json.Unmarshal(rawXKey.(json.RawMessage), &xKey)
-							if err != nil {
-								handler.responder.WriteError(ctx, writer, request.URL.Path, err)
-								return
-							}
-							listKeys = strings.Split(xKey, ",")
+						if nonconfigPath, found := database.FindNonconfig(underlyingNode, topProperty.Value, true); found {
+							handler.badRequestRestconf(writer, request, openapi.InvalidValueError(
+								request.URL.Path,
+								"Node '"+topKey+nonconfigPath+"' is not configuration data and cannot be written",
+							))
+							return
+						}
+						listKeys := database.ListKeys(topProperty.Value)
+						insertion, restconfError := query.insertion(topKey, topProperty.Value)
+						if restconfError != nil {
+							restconfError.ErrorPath = request.URL.Path
+							handler.badRequestRestconf(writer, request, *restconfError)
+							return
 						}
 						switch request.Method {
 						case "POST":
@@ -619,20 +557,22 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 							} else {
 								subKey = topKey
 							}
-							appendKey, err := db.Post(keyPath, underlyingNode, subKey, listKeys)
+							appendKey, err := db.Post(keyPath, underlyingNode, subKey, listKeys, insertion)
 							if err != nil {
 								switch err.(type) {
 								case *database.DataExistsError:
 									handler.conflict(writer, request)
 								case *database.KeyPathNotFoundError:
 									handler.notFound(writer, request)
+								case *database.PointNotFoundError:
+									handler.pointNotFound(writer, request, query.point)
 								default:
 									handler.badRequest(writer, request, err)
 									logger.Errorf("Post Error", err)
 								}
 								return
 							}
-							writer.Header().Add("Location", request.URL.String()+"/"+appendKey)
+							writer.Header().Add("Location", requestURL(request)+"/"+appendKey)
 							response.StatusCode = http.StatusCreated
 							break
 						case "PUT":
@@ -640,10 +580,15 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 							if handler.checkListKeyLeafValuesChanged(writer, request, underlyingNode, route, pathParameters, listKeys, ctx) {
 								return
 							}
-							created, err := db.Put(keyPath, underlyingNode)
+							created, err := db.Put(keyPath, underlyingNode, insertion)
 							if err != nil {
-								handler.badRequest(writer, request, err)
-								logger.Errorf("Put Error", err)
+								switch err.(type) {
+								case *database.PointNotFoundError:
+									handler.pointNotFound(writer, request, query.point)
+								default:
+									handler.badRequest(writer, request, err)
+									logger.Errorf("Put Error", err)
+								}
 								return
 							}
 							if created {
@@ -655,7 +600,7 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 							if handler.checkListKeyLeafValuesChanged(writer, request, underlyingNode, route, pathParameters, listKeys, ctx) {
 								return
 							}
-							err := db.Patch(keyPath, underlyingNode)
+							err := db.Patch(keyPath, underlyingNode, topProperty.Value)
 							if err != nil {
 								switch err.(type) {
 								case *database.KeyPathNotFoundError:
@@ -679,20 +624,13 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 				return
 			}
 		}
-		lastModified, err := db.GetLastModified()
-		if err != nil {
-			handler.responder.WriteError(ctx, writer, request.URL.Path, err)
-			return
-		} else {
-			writer.Header().Add("Last-Modified", lastModified)
-		}
-		eTag, err := db.GetETag()
+		metadata, err := db.Metadata(keyPath)
 		if err != nil {
-			handler.responder.WriteError(ctx, writer, request.URL.Path, err)
+			handler.internalError(ctx, writer, request, err)
 			return
-		} else {
-			writer.Header().Add("ETag", eTag)
 		}
+		writer.Header().Add("Last-Modified", metadata.LastModified.Format(http.TimeFormat))
+		writer.Header().Add("ETag", metadata.ETag)
 	} else { // DELETE
 		err := db.Delete(keyPath)
 		if err != nil {
@@ -706,6 +644,7 @@ func (handler *responseGeneratorHandler) ServeHTTP(writer http.ResponseWriter, r
 			return
 		}
 	}
+	response.ContentType = negotiatedContentType(request, response.ContentType)
 	handler.responder.WriteResponse(ctx, writer, request.URL.Path, response)
 }
 
@@ -734,17 +673,27 @@ func (handler *responseGeneratorHandler) checkListKeyLeafValuesChanged(writer ht
 	return false
 }
 
-func (handler *responseGeneratorHandler) writeError(writer http.ResponseWriter, statusCode int, restconfErrors openapi.RestconfErrors) {
-	writer.Header().Set("Content-Type", "application/yang-data+json; charset=UTF-8")
-	writer.WriteHeader(statusCode)
-
-	marshal, _ := json.Marshal(restconfErrors)
+func (handler *responseGeneratorHandler) writeError(writer http.ResponseWriter, request *http.Request, statusCode int, restconfErrors openapi.RestconfErrors) {
+	writeRestconfErrors(writer, request, statusCode, restconfErrors, handler.xmlNamespaces)
+}
 
-	_, _ = writer.Write(marshal)
+// internalError reports an unexpected failure of the server as an "operation-failed" error,
+// encoded like the other RESTCONF errors in the media type negotiated by the client.
+func (handler *responseGeneratorHandler) internalError(ctx context.Context, writer http.ResponseWriter, request *http.Request, err error) {
+	logger := logcontext.LoggerFromContext(ctx)
+	logger.Errorf("Server Internal Error", err)
+	handler.writeError(writer, request,
+		http.StatusInternalServerError,
+		openapi.NewRestconfErrors(openapi.RestconfError{
+			ErrorType:    openapi.ErrorTypeApplication,
+			ErrorTag:     openapi.ErrorTagOperationFailed,
+			ErrorPath:    request.URL.Path,
+			ErrorMessage: err.Error(),
+		}))
 }
 
 func (handler *responseGeneratorHandler) notFound(writer http.ResponseWriter, request *http.Request) {
-	handler.writeError(writer,
+	handler.writeError(writer, request,
 		http.StatusNotFound,
 		openapi.NewRestconfErrors(openapi.RestconfError{
 			ErrorType:    openapi.ErrorTypeProtocol,
@@ -755,7 +704,7 @@ func (handler *responseGeneratorHandler) notFound(writer http.ResponseWriter, re
 }
 
 func (handler *responseGeneratorHandler) conflict(writer http.ResponseWriter, request *http.Request) {
-	handler.writeError(writer,
+	handler.writeError(writer, request,
 		http.StatusConflict,
 		openapi.NewRestconfErrors(openapi.RestconfError{
 			ErrorType:    openapi.ErrorTypeProtocol,
@@ -766,7 +715,7 @@ func (handler *responseGeneratorHandler) conflict(writer http.ResponseWriter, re
 }
 
 func (handler *responseGeneratorHandler) badRequest(writer http.ResponseWriter, request *http.Request, err error) {
-	handler.writeError(writer,
+	handler.writeError(writer, request,
 		http.StatusBadRequest,
 		openapi.NewRestconfErrors(openapi.RestconfError{
 			ErrorType:    openapi.ErrorTypeProtocol,
@@ -777,7 +726,24 @@ func (handler *responseGeneratorHandler) badRequest(writer http.ResponseWriter,
 }
 
 func (handler *responseGeneratorHandler) badRequestRestconf(writer http.ResponseWriter, request *http.Request, errs ...openapi.RestconfError) {
-	handler.writeError(writer,
+	handler.writeError(writer, request,
 		http.StatusBadRequest,
 		openapi.NewRestconfErrors(errs...))
 }
+
+func (handler *responseGeneratorHandler) pointNotFound(writer http.ResponseWriter, request *http.Request, point string) {
+	restconfError := openapi.BadAttributeError("point", "The insertion point '"+point+"' does not exist")
+	restconfError.ErrorPath = request.URL.Path
+	handler.badRequestRestconf(writer, request, restconfError)
+}
+
+func (handler *responseGeneratorHandler) malformedMessage(writer http.ResponseWriter, request *http.Request, err error) {
+	handler.writeError(writer, request,
+		http.StatusBadRequest,
+		openapi.NewRestconfErrors(openapi.RestconfError{
+			ErrorType:    openapi.ErrorTypeRpc,
+			ErrorTag:     openapi.ErrorTagMalformedMessage,
+			ErrorPath:    request.URL.Path,
+			ErrorMessage: "Cannot decode body: " + err.Error(),
+		}))
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseGeneratorHandler_InternalError_XMLAccepted_XMLErrorsWritten(t *testing.T) {
+	handler := &responseGeneratorHandler{}
+	request := httptest.NewRequest(http.MethodGet, "/restconf/data/ex:top", nil)
+	request.Header.Set("Accept", yangDataXML)
+	recorder := httptest.NewRecorder()
+
+	handler.internalError(context.Background(), recorder, request, errors.New("cannot load the data"))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, yangDataXML+"; charset=UTF-8", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "<errors")
+	assert.Contains(t, recorder.Body.String(), "<error-tag>operation-failed</error-tag>")
+	assert.Contains(t, recorder.Body.String(), "<error-message>cannot load the data</error-message>")
+}
+
+func TestResponseGeneratorHandler_InternalError_NoAccept_JSONErrorsWritten(t *testing.T) {
+	handler := &responseGeneratorHandler{}
+	request := httptest.NewRequest(http.MethodGet, "/restconf/data/ex:top", nil)
+	recorder := httptest.NewRecorder()
+
+	handler.internalError(context.Background(), recorder, request, errors.New("cannot load the data"))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, yangDataJSON+"; charset=UTF-8", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"ietf-restconf:errors":{"error":[{
+		"error-type":"application",
+		"error-tag":"operation-failed",
+		"error-path":"/restconf/data/ex:top",
+		"error-message":"cannot load the data"
+	}]}}`, recorder.Body.String())
+}
@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"context"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/generator"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	datastoresPrefix = "/restconf/ds/"
+	restconfDataPath = "/restconf/data"
+)
+
+// suffixes of the files holding the datastores other than running, next to the database file
+const (
+	intendedFileSuffix    = ".intended"
+	operationalFileSuffix = ".operational"
+	// the state data merged into the operational datastore, maintained outside of the mock
+	stateFileSuffix = ".state"
+)
+
+type datastoreContextKey struct{}
+
+// datastoreRequest describes the NMDA datastore (RFC 8527) targeted by a request under /restconf/ds/.
+type datastoreRequest struct {
+	name string
+	url  string // the URL requested by the client, before it was rewritten under /restconf/data
+}
+
+func (datastore datastoreRequest) readOnly() bool {
+	return datastore.name != openapi.DatastoreRunning && datastore.name != openapi.DatastoreCandidate
+}
+
+// configOnly tells whether the datastore holds configuration data only.
+func (datastore datastoreRequest) configOnly() bool {
+	return datastore.name != openapi.DatastoreOperational
+}
+
+func requestDatastore(request *http.Request) (datastoreRequest, bool) {
+	datastore, ok := request.Context().Value(datastoreContextKey{}).(datastoreRequest)
+	return datastore, ok
+}
+
+// requestURL returns the URL requested by the client, whatever datastore it targets.
+func requestURL(request *http.Request) string {
+	if datastore, ok := requestDatastore(request); ok {
+		return datastore.url
+	}
+	return request.URL.String()
+}
+
+// datastoreHandler maps the datastore resources under /restconf/ds/ to the data resources of the specification.
+// The targeted datastore is kept in the request context, for the next handlers to read from and write to it.
+type datastoreHandler struct {
+	nextHandler http.Handler
+}
+
+func (handler *datastoreHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if !strings.HasPrefix(request.URL.Path, datastoresPrefix) {
+		handler.nextHandler.ServeHTTP(writer, request)
+		return
+	}
+	name, resource, _ := strings.Cut(strings.TrimPrefix(request.URL.Path, datastoresPrefix), "/")
+	switch name {
+	case openapi.DatastoreRunning, openapi.DatastoreCandidate, openapi.DatastoreIntended, openapi.DatastoreOperational:
+	default:
+		writeRestconfErrors(writer, request, http.StatusNotFound, openapi.NewRestconfErrors(openapi.RestconfError{
+			ErrorType:    openapi.ErrorTypeProtocol,
+			ErrorTag:     openapi.ErrorTagInvalidValue,
+			ErrorPath:    request.URL.Path,
+			ErrorMessage: "Unknown datastore '" + name + "'",
+		}), nil)
+		return
+	}
+
+	ctx := context.WithValue(request.Context(), datastoreContextKey{}, datastoreRequest{
+		name: name,
+		url:  request.URL.String(),
+	})
+	rewritten := request.Clone(ctx)
+	rewritten.URL.Path = dataResourcePath(resource)
+	if request.URL.RawPath != "" {
+		_, rawResource, _ := strings.Cut(strings.TrimPrefix(request.URL.RawPath, datastoresPrefix), "/")
+		rewritten.URL.RawPath = dataResourcePath(rawResource)
+	}
+	handler.nextHandler.ServeHTTP(writer, rewritten)
+}
+
+func dataResourcePath(resource string) string {
+	if resource == "" {
+		return restconfDataPath
+	}
+	return restconfDataPath + "/" + resource
+}
+
+func isDatastoreRoot(request *http.Request) bool {
+	_, ok := requestDatastore(request)
+	return ok && request.URL.Path == restconfDataPath
+}
+
+// serveDatastoreRoot answers with the whole content of a datastore, wrapped in the "data" container of ietf-restconf.
+func (handler *responseGeneratorHandler) serveDatastoreRoot(writer http.ResponseWriter, request *http.Request, datastore datastoreRequest, query restconfQuery) {
+	ctx := request.Context()
+	err := handler.propagator.ensure()
+	if err != nil {
+		handler.internalError(ctx, writer, request, err)
+		return
+	}
+	db, err := handler.loadDatastore(datastore.name)
+	if err != nil {
+		db = database.NewDatabase()
+	}
+	metadata, err := db.Metadata("$")
+	if err != nil {
+		handler.internalError(ctx, writer, request, err)
+		return
+	}
+	if statusCode := evaluatePreconditions(request, metadata, true); statusCode != 0 {
+		handler.writePreconditionResult(writer, request, statusCode, metadata)
+		return
+	}
+	content, err := db.Content.Unpack()
+	if err != nil {
+		handler.internalError(ctx, writer, request, err)
+		return
+	}
+	content, err = applyRetrievalQuery(content, query, handler.propagator.schema)
+	if err != nil {
+		handler.internalError(ctx, writer, request, err)
+		return
+	}
+	writer.Header().Set("Last-Modified", metadata.LastModified.Format(http.TimeFormat))
+	writer.Header().Set("ETag", metadata.ETag)
+	handler.responder.WriteResponse(ctx, writer, request.URL.Path, &generator.Response{
+		StatusCode:  http.StatusOK,
+		ContentType: negotiatedContentType(request, yangDataJSON),
+		Data:        map[string]interface{}{"ietf-restconf:data": content},
+	})
+}
+
+// loadDatastore loads the content of the datastore. Until it is edited, the candidate datastore is a copy of running.
+func (handler *responseGeneratorHandler) loadDatastore(datastore string) (*database.Database, error) {
+	if datastore == openapi.DatastoreCandidate {
+		return database.LoadCandidate(handler.databasePath)
+	}
+	return database.Load(handler.propagator.filename(datastore))
+}
+
+// propagator applies the content of the running datastore to the intended and operational ones,
+// after a delay standing for the time a device takes to put its configuration in effect.
+// The intended datastore holds the configuration data of running,
+// the operational one holds running merged with the state data of the state file.
+type propagator struct {
+	databasePath string
+	schema       *openapi3.Schema // describes the top level nodes of the datastores
+	delay        time.Duration
+	m            sync.Mutex
+	scheduled    uint64
+	applied      uint64
+	initialized  bool
+}
+
+func newPropagator(databasePath string, spec *openapi3.T, delay time.Duration) *propagator {
+	return &propagator{
+		databasePath: databasePath,
+		schema:       datastoreSchema(spec),
+		delay:        delay,
+	}
+}
+
+// filename returns the file holding the datastore. Running is the database of the unified /restconf/data resources.
+func (propagator *propagator) filename(datastore string) string {
+	switch datastore {
+	case openapi.DatastoreCandidate:
+		return database.CandidateFilename(propagator.databasePath)
+	case openapi.DatastoreIntended:
+		return propagator.databasePath + intendedFileSuffix
+	case openapi.DatastoreOperational:
+		return propagator.databasePath + operationalFileSuffix
+	}
+	return propagator.databasePath
+}
+
+// schedule propagates the current content of running once the delay is elapsed.
+// The content of a later change is never overwritten by the one of an earlier change.
+func (propagator *propagator) schedule(running *database.Database) (<-chan error, error) {
+	snapshot, err := running.Copy()
+	if err != nil {
+		return nil, err
+	}
+	propagator.m.Lock()
+	propagator.scheduled++
+	sequence := propagator.scheduled
+	propagator.m.Unlock()
+
+	done := make(chan error, 1)
+	if propagator.delay <= 0 {
+		done <- propagator.apply(snapshot, sequence)
+		return done, nil
+	}
+	time.AfterFunc(propagator.delay, func() {
+		done <- propagator.apply(snapshot, sequence)
+	})
+	return done, nil
+}
+
+// ensure creates the intended and operational datastores from running if they do not exist yet.
+func (propagator *propagator) ensure() error {
+	propagator.m.Lock()
+	defer propagator.m.Unlock()
+	if propagator.initialized {
+		return nil
+	}
+	_, intendedErr := os.Stat(propagator.filename(openapi.DatastoreIntended))
+	_, operationalErr := os.Stat(propagator.filename(openapi.DatastoreOperational))
+	if intendedErr == nil && operationalErr == nil {
+		propagator.initialized = true
+		return nil
+	}
+	running, err := database.Load(propagator.databasePath)
+	if err != nil {
+		running = database.NewDatabase()
+	}
+	return propagator.propagate(running)
+}
+
+func (propagator *propagator) apply(running *database.Database, sequence uint64) error {
+	propagator.m.Lock()
+	defer propagator.m.Unlock()
+	if sequence < propagator.applied {
+		return nil
+	}
+	propagator.applied = sequence
+	return propagator.propagate(running)
+}
+
+func (propagator *propagator) propagate(running *database.Database) error {
+	intended, err := running.Copy()
+	if err != nil {
+		return err
+	}
+	for _, key := range intended.Content.Keys() {
+		child, err := intended.Content.GetKey(key)
+		if err != nil {
+			return err
+		}
+		childSchema := database.ChildSchema(propagator.schema, key)
+		if !database.IsConfig(childSchema, true) {
+			err = intended.Content.DeleteKey(key)
+		} else {
+			err = database.FilterContent(child, childSchema, database.ContentConfig)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	err = intended.Save(propagator.filename(openapi.DatastoreIntended))
+	if err != nil {
+		return err
+	}
+
+	operational, err := running.Copy()
+	if err != nil {
+		return err
+	}
+	state, err := database.Load(propagator.databasePath + stateFileSuffix)
+	if err == nil && state != nil {
+		err = database.MergeNode(operational.Content, state.Content, propagator.schema)
+		if err != nil {
+			return err
+		}
+	}
+	err = operational.Save(propagator.filename(openapi.DatastoreOperational))
+	if err != nil {
+		return err
+	}
+	propagator.initialized = true
+	return nil
+}
+
+// datastoreSchema gathers the schemas of the top level data nodes of the specification into the schema of a datastore.
+func datastoreSchema(spec *openapi3.T) *openapi3.Schema {
+	schema := openapi3.NewObjectSchema()
+	if spec == nil {
+		return schema
+	}
+	for path, item := range spec.Paths {
+		node := strings.TrimPrefix(path, restconfDataPath+"/")
+		if node == path || strings.ContainsAny(node, "/=") || item.Get == nil {
+			continue
+		}
+		responseSchema := operationDataSchema(item.Get)
+		if responseSchema == nil {
+			continue
+		}
+		for name, property := range responseSchema.Properties {
+			schema.Properties[name] = property
+		}
+	}
+	return schema
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/generator"
+	"github.com/muonsoft/openapi-mock/internal/openapi/responder"
+	"net/http"
+	"strings"
+)
+
+const (
+	restconfRoot           = "/restconf"
+	hostMetaPath           = "/.well-known/host-meta"
+	hostMetaJSONPath       = "/.well-known/host-meta.json"
+	yangLibraryVersionPath = restconfRoot + "/yang-library-version"
+)
+
+const hostMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<XRD xmlns="http://docs.oasis-open.org/ns/xri/xrd-1.0">
+    <Link rel="restconf" href="` + restconfRoot + `"/>
+</XRD>
+`
+
+// discoveryHandler serves the resources clients use to discover the RESTCONF API root (RFC 8040 section 3.1),
+// which do not depend on the loaded specification.
+type discoveryHandler struct {
+	responder   responder.Responder
+	nextHandler http.Handler
+}
+
+func (handler *discoveryHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	path := strings.TrimSuffix(request.URL.Path, "/")
+	if path != hostMetaPath && path != hostMetaJSONPath && path != restconfRoot && path != yangLibraryVersionPath {
+		handler.nextHandler.ServeHTTP(writer, request)
+		return
+	}
+
+	switch request.Method {
+	case http.MethodOptions:
+		writer.Header().Set("Allow", "OPTIONS, HEAD, GET")
+		writer.WriteHeader(http.StatusOK)
+		return
+	case http.MethodGet, http.MethodHead:
+	default:
+		writer.Header().Set("Allow", "OPTIONS, HEAD, GET")
+		writeRestconfErrors(writer, request, http.StatusMethodNotAllowed, openapi.NewRestconfErrors(openapi.RestconfError{
+			ErrorType:    openapi.ErrorTypeProtocol,
+			ErrorTag:     openapi.ErrorTagOperationNotSuported,
+			ErrorPath:    request.URL.Path,
+			ErrorMessage: "Method Not Allowed",
+		}), nil)
+		return
+	}
+
+	switch path {
+	case hostMetaPath:
+		writer.Header().Set("Content-Type", "application/xrd+xml; charset=utf-8")
+		writer.WriteHeader(http.StatusOK)
+		_, _ = writer.Write([]byte(hostMeta))
+	case hostMetaJSONPath:
+		marshal, _ := json.Marshal(map[string]interface{}{
+			"links": []map[string]string{{"rel": "restconf", "href": restconfRoot}},
+		})
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(http.StatusOK)
+		_, _ = writer.Write(marshal)
+	case restconfRoot:
+		handler.respond(writer, request, openapi.NewRestconfRoot())
+	case yangLibraryVersionPath:
+		handler.respond(writer, request, openapi.NewYangLibraryVersion())
+	}
+}
+
+func (handler *discoveryHandler) respond(writer http.ResponseWriter, request *http.Request, data interface{}) {
+	handler.responder.WriteResponse(request.Context(), writer, request.URL.Path, &generator.Response{
+		StatusCode:  http.StatusOK,
+		ContentType: negotiatedContentType(request, yangDataJSON),
+		Data:        data,
+	})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muonsoft/openapi-mock/internal/openapi/responder"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDiscoveryHandler(served *bool) *discoveryHandler {
+	return &discoveryHandler{responder: responder.New(nil), nextHandler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		*served = true
+	})}
+}
+
+func TestDiscoveryHandler_ServeHTTP_HostMeta_RestconfRootLinked(t *testing.T) {
+	served := false
+	handler := newDiscoveryHandler(&served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, hostMetaPath, nil))
+
+	assert.False(t, served)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/xrd+xml; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), `<Link rel="restconf" href="/restconf"/>`)
+}
+
+func TestDiscoveryHandler_ServeHTTP_HostMetaJSON_RestconfRootLinked(t *testing.T) {
+	served := false
+	handler := newDiscoveryHandler(&served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, hostMetaJSONPath, nil))
+
+	assert.False(t, served)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"links":[{"rel":"restconf","href":"/restconf"}]}`, recorder.Body.String())
+}
+
+func TestDiscoveryHandler_ServeHTTP_RestconfRoot_RootResourceReturned(t *testing.T) {
+	served := false
+	handler := newDiscoveryHandler(&served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, restconfRoot+"/", nil))
+
+	assert.False(t, served)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, yangDataJSON+"; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"ietf-restconf:restconf":{"data":{},"operations":{},"yang-library-version":"2019-01-04"}}`, recorder.Body.String())
+}
+
+func TestDiscoveryHandler_ServeHTTP_YangLibraryVersionAsXML_XMLVersionReturned(t *testing.T) {
+	served := false
+	handler := newDiscoveryHandler(&served)
+	request := httptest.NewRequest(http.MethodGet, yangLibraryVersionPath, nil)
+	request.Header.Set("Accept", yangDataXML)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, request)
+
+	assert.False(t, served)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, yangDataXML+"; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), ">2019-01-04</yang-library-version>")
+}
+
+func TestDiscoveryHandler_ServeHTTP_Post_MethodNotAllowed(t *testing.T) {
+	served := false
+	handler := newDiscoveryHandler(&served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, restconfRoot, nil))
+
+	assert.False(t, served)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, "OPTIONS, HEAD, GET", recorder.Header().Get("Allow"))
+}
+
+func TestDiscoveryHandler_ServeHTTP_DataResource_PassedToNextHandler(t *testing.T) {
+	served := false
+	handler := newDiscoveryHandler(&served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, restconfRoot+"/data/ex:top", nil))
+
+	assert.True(t, served)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/routers"
+	"github.com/go-ozzo/ozzo-routing/v2/content"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	yangDataJSON = "application/yang-data+json"
+	yangDataXML  = "application/yang-data+xml"
+)
+
+// isXMLMediaType tells whether the media type of a Content-Type or Accept header value is an XML one.
+func isXMLMediaType(value string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+	return err == nil && (mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml"))
+}
+
+// prefersXML tells whether the response to the request is to be encoded in XML.
+// Without a specific Accept header, the response is encoded like the request body (RFC 8040 section 5.2).
+func prefersXML(request *http.Request) bool {
+	accept := strings.TrimSpace(request.Header.Get("Accept"))
+	if accept == "" || accept == "*/*" {
+		return isXMLMediaType(request.Header.Get("Content-Type"))
+	}
+	return isXMLMediaType(content.NegotiateContentType(request, []string{yangDataJSON, yangDataXML}, yangDataJSON))
+}
+
+// decodeXMLBody converts the XML encoded request body into its JSON encoding, using the request body schema of the route.
+func (handler *responseGeneratorHandler) decodeXMLBody(bodyData []byte, route *routers.Route) ([]byte, error) {
+	data, err := yangxml.Unmarshal(bodyData, requestDataSchema(route), handler.xmlNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+// negotiatedContentType switches a YANG data response to the XML encoding when the client asks for it.
+func negotiatedContentType(request *http.Request, contentType string) string {
+	if contentType == yangDataJSON && prefersXML(request) {
+		return yangDataXML
+	}
+	return contentType
+}
+
+// writeRestconfErrors writes the RESTCONF "errors" document, encoded in XML when the client negotiates it.
+func writeRestconfErrors(writer http.ResponseWriter, request *http.Request, statusCode int, restconfErrors openapi.RestconfErrors, namespaces yangxml.Namespaces) {
+	var marshal []byte
+	var err error
+	if prefersXML(request) {
+		marshal, err = yangxml.Marshal(restconfErrors, namespaces)
+		if err == nil {
+			writer.Header().Set("Content-Type", yangDataXML+"; charset=UTF-8")
+		}
+	}
+	if marshal == nil || err != nil {
+		marshal, _ = json.Marshal(restconfErrors)
+		writer.Header().Set("Content-Type", yangDataJSON+"; charset=UTF-8")
+	}
+	writer.WriteHeader(statusCode)
+
+	_, _ = writer.Write(marshal)
+}
@@ -0,0 +1,389 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+	"github.com/muonsoft/openapi-mock/pkg/logcontext"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	netconfCommitPath         = "/restconf/operations/ietf-netconf:commit"
+	netconfCancelCommitPath   = "/restconf/operations/ietf-netconf:cancel-commit"
+	netconfDiscardChangesPath = "/restconf/operations/ietf-netconf:discard-changes"
+	netconfLockPath           = "/restconf/operations/ietf-netconf:lock"
+	netconfUnlockPath         = "/restconf/operations/ietf-netconf:unlock"
+)
+
+func isNetconfOperation(path string) bool {
+	switch path {
+	case netconfCommitPath, netconfCancelCommitPath, netconfDiscardChangesPath, netconfLockPath, netconfUnlockPath:
+		return true
+	}
+	return false
+}
+
+// netconfState holds the locks of the configuration datastores and the pending confirmed commit (RFC 6241 sections 7.5 and 8.4).
+// Locks and confirmed commits are owned by clients, see clientIdentity.
+type netconfState struct {
+	m       sync.Mutex
+	locks   map[string]string
+	pending *confirmedCommit
+}
+
+type confirmedCommit struct {
+	backup     *database.Database // running before the first confirmed commit
+	owner      string
+	persist    string
+	generation uint64 // tells the timeout of the last confirmed commit from the ones it extended
+	timer      *time.Timer
+}
+
+// clientIdentity identifies the client of a request by its user name, or by its address without authentication.
+func clientIdentity(request *http.Request) string {
+	if username, _, ok := request.BasicAuth(); ok {
+		return "user:" + username
+	}
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	return "host:" + host
+}
+
+// lockedByOther tells whether the datastore is locked by another client than the one of the request.
+// The caller must hold the lock of the state.
+func (state *netconfState) lockedByOther(datastore string, request *http.Request) bool {
+	owner, locked := state.locks[datastore]
+	return locked && owner != clientIdentity(request)
+}
+
+// checkLock denies the request writing into the datastore if another client locked it.
+func (handler *responseGeneratorHandler) checkLock(writer http.ResponseWriter, request *http.Request, datastore string) bool {
+	handler.netconf.m.Lock()
+	defer handler.netconf.m.Unlock()
+	if handler.netconf.lockedByOther(datastore, request) {
+		handler.lockDenied(writer, request, datastore)
+		return false
+	}
+	return true
+}
+
+// serveNetconfOperation executes the operations of ietf-netconf managing the candidate datastore and the locks,
+// which are implemented by the mock whatever the loaded specification.
+func (handler *responseGeneratorHandler) serveNetconfOperation(writer http.ResponseWriter, request *http.Request) {
+	var bodyData []byte
+	var err error
+	if request.Body != http.NoBody && request.Body != nil {
+		bodyData, err = ioutil.ReadAll(request.Body)
+		if err != nil {
+			handler.malformedMessage(writer, request, err)
+			return
+		}
+		defer request.Body.Close()
+	}
+	if len(bodyData) > 0 && isXMLMediaType(request.Header.Get("Content-Type")) {
+		var data map[string]interface{}
+		data, err = yangxml.Unmarshal(bodyData, nil, handler.xmlNamespaces)
+		if err == nil {
+			bodyData, err = json.Marshal(data)
+		}
+		if err != nil {
+			handler.malformedMessage(writer, request, err)
+			return
+		}
+	}
+
+	var statusCode int
+	var restconfError *openapi.RestconfError
+	switch request.URL.Path {
+	case netconfCommitPath, netconfCancelCommitPath:
+		var input openapi.NetconfCommitInput
+		if len(bodyData) > 0 {
+			err = json.Unmarshal(bodyData, &input)
+		}
+		if err == nil && request.URL.Path == netconfCommitPath {
+			statusCode, restconfError = handler.commit(request, input)
+		} else if err == nil {
+			statusCode, restconfError = handler.cancelCommit(request, input)
+		}
+	case netconfDiscardChangesPath:
+		statusCode, restconfError = handler.discardChanges(request)
+	case netconfLockPath, netconfUnlockPath:
+		var input openapi.NetconfLockInput
+		err = json.Unmarshal(bodyData, &input)
+		if err == nil && input.Datastore() == "" {
+			statusCode = http.StatusBadRequest
+			restconfError = &openapi.RestconfError{
+				ErrorType:    openapi.ErrorTypeProtocol,
+				ErrorTag:     openapi.ErrorTagMissingElement,
+				ErrorMessage: "The target datastore is missing",
+			}
+		} else if err == nil && request.URL.Path == netconfLockPath {
+			statusCode, restconfError = handler.lock(request, input.Datastore())
+		} else if err == nil {
+			statusCode, restconfError = handler.unlock(request, input.Datastore())
+		}
+	}
+	if err != nil {
+		handler.malformedMessage(writer, request, err)
+		return
+	}
+	if restconfError != nil {
+		restconfError.ErrorPath = request.URL.Path
+		handler.writeError(writer, request, statusCode, openapi.NewRestconfErrors(*restconfError))
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// commit replaces running with the candidate datastore.
+// A confirmed commit is rolled back unless a confirming commit is issued before its timeout.
+func (handler *responseGeneratorHandler) commit(request *http.Request, input openapi.NetconfCommitInput) (int, *openapi.RestconfError) {
+	timeout := time.Duration(openapi.DefaultConfirmTimeout) * time.Second
+	if input.Input.ConfirmTimeout != "" {
+		seconds, err := strconv.ParseUint(input.Input.ConfirmTimeout.String(), 10, 32)
+		if err != nil || seconds == 0 {
+			restconfError := openapi.InvalidValueError("", "Invalid confirm-timeout '"+input.Input.ConfirmTimeout.String()+"'")
+			return http.StatusBadRequest, &restconfError
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	state := &handler.netconf
+	state.m.Lock()
+	defer state.m.Unlock()
+	for _, datastore := range []string{openapi.DatastoreRunning, openapi.DatastoreCandidate} {
+		if state.lockedByOther(datastore, request) {
+			restconfError := openapi.LockDeniedError(datastore)
+			return http.StatusConflict, &restconfError
+		}
+	}
+	if state.pending != nil || input.Input.PersistID != nil {
+		if statusCode, restconfError := state.checkConfirmingClient(request, input); restconfError != nil {
+			return statusCode, restconfError
+		}
+	}
+
+	running, err := database.Load(handler.databasePath)
+	if err != nil {
+		running = database.NewDatabase()
+	}
+	candidate, err := database.LoadCandidate(handler.databasePath)
+	if err != nil {
+		return operationFailed(err)
+	}
+	previous, err := running.Copy()
+	if err != nil {
+		return operationFailed(err)
+	}
+	pending := state.pending
+	if input.IsConfirmed() && pending == nil {
+		pending = &confirmedCommit{backup: previous}
+	}
+	err = running.Commit(candidate)
+	if err == nil {
+		err = running.Save(handler.databasePath)
+	}
+	if err == nil {
+		err = database.DiscardChanges(handler.databasePath)
+	}
+	if err != nil {
+		return operationFailed(err)
+	}
+	handler.propagate(request, running)
+	handler.publishChanges(request, previous, running)
+
+	if pending != nil && pending.timer != nil {
+		pending.timer.Stop()
+	}
+	if !input.IsConfirmed() {
+		state.pending = nil
+		return http.StatusNoContent, nil
+	}
+	pending.owner = clientIdentity(request)
+	pending.persist = input.Input.Persist
+	pending.generation++
+	generation := pending.generation
+	pending.timer = time.AfterFunc(timeout, func() {
+		handler.rollback(pending, generation)
+	})
+	state.pending = pending
+	return http.StatusNoContent, nil
+}
+
+// cancelCommit rolls the pending confirmed commit back.
+func (handler *responseGeneratorHandler) cancelCommit(request *http.Request, input openapi.NetconfCommitInput) (int, *openapi.RestconfError) {
+	state := &handler.netconf
+	state.m.Lock()
+	pending := state.pending
+	if pending == nil {
+		state.m.Unlock()
+		restconfError := openapi.InvalidValueError("", "No confirmed commit is pending")
+		return http.StatusBadRequest, &restconfError
+	}
+	statusCode, restconfError := state.checkConfirmingClient(request, input)
+	generation := pending.generation
+	state.m.Unlock()
+	if restconfError != nil {
+		return statusCode, restconfError
+	}
+	err := handler.rollback(pending, generation)
+	if err != nil {
+		return operationFailed(err)
+	}
+	return http.StatusNoContent, nil
+}
+
+// checkConfirmingClient tells whether the client may confirm or cancel the pending confirmed commit:
+// the persist-id must match the persist of a persistent confirmed commit, and the client must own it otherwise.
+// The caller must hold the lock of the state.
+func (state *netconfState) checkConfirmingClient(request *http.Request, input openapi.NetconfCommitInput) (int, *openapi.RestconfError) {
+	if input.Input.PersistID != nil {
+		if state.pending == nil || state.pending.persist != *input.Input.PersistID {
+			restconfError := openapi.InvalidValueError("", "No confirmed commit is pending with persist-id '"+*input.Input.PersistID+"'")
+			return http.StatusBadRequest, &restconfError
+		}
+		return 0, nil
+	}
+	if state.pending.persist != "" || state.pending.owner != clientIdentity(request) {
+		restconfError := openapi.RestconfError{
+			ErrorType:    openapi.ErrorTypeProtocol,
+			ErrorTag:     openapi.ErrorTagInUse,
+			ErrorMessage: "A confirmed commit of another client is pending",
+		}
+		return http.StatusConflict, &restconfError
+	}
+	return 0, nil
+}
+
+// rollback restores running as it was before the pending confirmed commit,
+// unless the commit was confirmed or extended by another one in the meantime.
+func (handler *responseGeneratorHandler) rollback(pending *confirmedCommit, generation uint64) error {
+	state := &handler.netconf
+	state.m.Lock()
+	defer state.m.Unlock()
+	if state.pending != pending || pending.generation != generation {
+		return nil
+	}
+	state.pending = nil
+	pending.timer.Stop()
+	running, err := database.Load(handler.databasePath)
+	if err != nil {
+		running = database.NewDatabase()
+	}
+	err = pending.backup.Save(handler.databasePath)
+	if err != nil {
+		return err
+	}
+	_, err = handler.propagator.schedule(pending.backup)
+	if err != nil {
+		return err
+	}
+	// the rollback is notified to every subscription, as no client wrote it
+	return handler.notifyChanges(running, pending.backup, "")
+}
+
+func (handler *responseGeneratorHandler) discardChanges(request *http.Request) (int, *openapi.RestconfError) {
+	state := &handler.netconf
+	state.m.Lock()
+	defer state.m.Unlock()
+	if state.lockedByOther(openapi.DatastoreCandidate, request) {
+		restconfError := openapi.LockDeniedError(openapi.DatastoreCandidate)
+		return http.StatusConflict, &restconfError
+	}
+	err := database.DiscardChanges(handler.databasePath)
+	if err != nil {
+		return operationFailed(err)
+	}
+	return http.StatusNoContent, nil
+}
+
+func (handler *responseGeneratorHandler) lock(request *http.Request, datastore string) (int, *openapi.RestconfError) {
+	state := &handler.netconf
+	state.m.Lock()
+	defer state.m.Unlock()
+	if _, locked := state.locks[datastore]; locked {
+		restconfError := openapi.LockDeniedError(datastore)
+		return http.StatusConflict, &restconfError
+	}
+	if state.locks == nil {
+		state.locks = map[string]string{}
+	}
+	state.locks[datastore] = clientIdentity(request)
+	return http.StatusNoContent, nil
+}
+
+func (handler *responseGeneratorHandler) unlock(request *http.Request, datastore string) (int, *openapi.RestconfError) {
+	state := &handler.netconf
+	state.m.Lock()
+	defer state.m.Unlock()
+	if _, locked := state.locks[datastore]; !locked {
+		restconfError := openapi.RestconfError{
+			ErrorType:    openapi.ErrorTypeProtocol,
+			ErrorTag:     openapi.ErrorTagOperationFailed,
+			ErrorMessage: "The datastore '" + datastore + "' is not locked",
+		}
+		return http.StatusPreconditionFailed, &restconfError
+	}
+	if state.lockedByOther(datastore, request) {
+		restconfError := openapi.LockDeniedError(datastore)
+		return http.StatusConflict, &restconfError
+	}
+	delete(state.locks, datastore)
+	return http.StatusNoContent, nil
+}
+
+// propagate schedules the propagation of the changes of running to the intended and operational datastores.
+func (handler *responseGeneratorHandler) propagate(request *http.Request, running *database.Database) {
+	logger := logcontext.LoggerFromContext(request.Context())
+	done, err := handler.propagator.schedule(running)
+	if err != nil {
+		logger.Errorf("Datastore propagation error", err)
+		return
+	}
+	go func() {
+		if err := <-done; err != nil {
+			logger.Errorf("Datastore propagation error", err)
+		}
+	}()
+}
+
+// publishChanges notifies the subscriptions of the changes the client of the request made to running.
+func (handler *responseGeneratorHandler) publishChanges(request *http.Request, previous *database.Database, running *database.Database) {
+	originator := ""
+	if handler.suppressEcho {
+		originator = clientIdentity(request)
+	}
+	err := handler.notifyChanges(previous, running, originator)
+	if err != nil {
+		logcontext.LoggerFromContext(request.Context()).Errorf("Change notification error", err)
+	}
+}
+
+// notifyChanges notifies the subscriptions of the changes between two versions of running,
+// except the subscriptions of the originator when given.
+func (handler *responseGeneratorHandler) notifyChanges(previous *database.Database, running *database.Database, originator string) error {
+	return subscriptionCenter.Publish(previous.Content, running.Content, originator)
+}
+
+func operationFailed(err error) (int, *openapi.RestconfError) {
+	return http.StatusInternalServerError, &openapi.RestconfError{
+		ErrorType:    openapi.ErrorTypeApplication,
+		ErrorTag:     openapi.ErrorTagOperationFailed,
+		ErrorMessage: err.Error(),
+	}
+}
+
+func (handler *responseGeneratorHandler) lockDenied(writer http.ResponseWriter, request *http.Request, datastore string) {
+	restconfError := openapi.LockDeniedError(datastore)
+	restconfError.ErrorPath = request.URL.Path
+	handler.writeError(writer, request, http.StatusConflict, openapi.NewRestconfErrors(restconfError))
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+const networksSchemaJSON = `{
+	"type": "object",
+	"properties": {
+		"ietf-network:networks": {
+			"type": "object",
+			"properties": {
+				"network": {
+					"type": "array",
+					"x-key": "network-id",
+					"items": {
+						"type": "object",
+						"properties": {
+							"network-id": {"type": "string"},
+							"node": {
+								"type": "array",
+								"x-key": "node-id",
+								"items": {"type": "object", "properties": {"node-id": {"type": "string"}, "name": {"type": "string"}}}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// changeNotification is the push-change-update notification of a subscription, as received on its stream.
+type changeNotification struct {
+	Notification struct {
+		PushChangeUpdate struct {
+			SubscriptionID   uint32            `json:"subscription-id"`
+			DatastoreChanges openapi.YangPatch `json:"datastore-changes"`
+		} `json:"ietf-yang-push:push-change-update"`
+	} `json:"ietf-restconf:notification"`
+}
+
+func newPublishingHandler(t *testing.T, suppressEcho bool) *responseGeneratorHandler {
+	var schema openapi3.Schema
+	err := json.Unmarshal([]byte(networksSchemaJSON), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscriptionCenter.SetDatastore(nil, &schema)
+	return &responseGeneratorHandler{propagator: &propagator{schema: &schema}, suppressEcho: suppressEcho}
+}
+
+func networksDatabase(nodes string) *database.Database {
+	return &database.Database{
+		Content: ajson.Must(ajson.Unmarshal([]byte(`{"ietf-network:networks":{"network":[{"network-id":"n1","node":[` + nodes + `]}]}}`))),
+	}
+}
+
+// inTempDir runs the test from a temporary directory, where the subscriptions are saved.
+func inTempDir(t *testing.T) {
+	directory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(directory)
+	})
+}
+
+// subscribeToNodes establishes a subscription to the nodes on behalf of the owner,
+// and returns the notifications received on its stream once the stream is connected.
+func subscribeToNodes(t *testing.T, owner string) <-chan changeNotification {
+	var input openapi.EstablishSubscriptionInput
+	err := json.Unmarshal([]byte(`{"ietf-subscribed-notifications:input":{"subscriptions":{"subscription":[{"object-type-info":"NODE"}]}}}`), &input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, restconfError := subscriptionCenter.Subscribe(input, owner)
+	if restconfError != nil {
+		t.Fatal(restconfError.ErrorMessage)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_ = subscriptionCenter.Connect(output.ID, 1, writer, request)
+	}))
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = response.Body.Close()
+		server.CloseClientConnections()
+		server.Close()
+	})
+
+	notifications := make(chan changeNotification, 10)
+	connected := make(chan struct{})
+	go func() {
+		heartbeats := 0
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, ":") {
+				// the connection is registered by the time of the second heartbeat
+				if heartbeats++; heartbeats == 2 {
+					close(connected)
+				}
+			} else if strings.HasPrefix(line, "data: ") && strings.Contains(line, "push-change-update") {
+				var notification changeNotification
+				if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &notification) == nil {
+					notifications <- notification
+				}
+			}
+		}
+	}()
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream of the subscription is not connected")
+	}
+	return notifications
+}
+
+func receive(t *testing.T, notifications <-chan changeNotification) []openapi.YangPatchEdit {
+	select {
+	case notification := <-notifications:
+		return notification.Notification.PushChangeUpdate.DatastoreChanges.YangPatch.Edit
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+		return nil
+	}
+}
+
+func TestResponseGeneratorHandler_PublishChanges_GivenWrite_EditPublished(t *testing.T) {
+	inTempDir(t)
+	notifications := subscribeToNodes(t, "host:198.51.100.7")
+	handler := newPublishingHandler(t, false)
+	tests := []struct {
+		method            string
+		previous          string
+		running           string
+		expectedOperation openapi.Operation
+		expectedTarget    string
+	}{
+		{http.MethodPut, `{"node-id":"a"}`, `{"node-id":"a"},{"node-id":"b"}`, "create", "/restconf/data/ietf-network:networks/network=n1/node=b"},
+		{http.MethodPatch, `{"node-id":"a"}`, `{"node-id":"a","name":"renamed"}`, "replace", "/restconf/data/ietf-network:networks/network=n1/node=a"},
+		{http.MethodDelete, `{"node-id":"a"},{"node-id":"b"}`, `{"node-id":"b"}`, "delete", "/restconf/data/ietf-network:networks/network=n1/node=a"},
+	}
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, "/restconf/data/ietf-network:networks", nil)
+
+			handler.publishChanges(request, networksDatabase(test.previous), networksDatabase(test.running))
+
+			edits := receive(t, notifications)
+			if assert.Len(t, edits, 1) {
+				assert.Equal(t, test.expectedOperation, edits[0].Operation)
+				assert.Equal(t, test.expectedTarget, edits[0].Target)
+			}
+		})
+	}
+}
+
+func TestResponseGeneratorHandler_PublishChanges_SuppressEcho_OriginatorSubscriptionsSkipped(t *testing.T) {
+	inTempDir(t)
+	handler := newPublishingHandler(t, true)
+	request := httptest.NewRequest(http.MethodPut, "/restconf/data/ietf-network:networks", nil)
+	request.RemoteAddr = "192.0.2.1:50000"
+	otherRequest := httptest.NewRequest(http.MethodPut, "/restconf/data/ietf-network:networks", nil)
+	otherRequest.RemoteAddr = "198.51.100.7:50000"
+	originatorNotifications := subscribeToNodes(t, clientIdentity(request))
+	otherNotifications := subscribeToNodes(t, clientIdentity(otherRequest))
+
+	handler.publishChanges(request, networksDatabase(`{"node-id":"a"}`), networksDatabase(`{"node-id":"a"},{"node-id":"b"}`))
+	otherEdits := receive(t, otherNotifications)
+	handler.publishChanges(otherRequest, networksDatabase(`{"node-id":"a"},{"node-id":"b"}`), networksDatabase(`{"node-id":"b"}`))
+	originatorEdits := receive(t, originatorNotifications)
+
+	if assert.Len(t, otherEdits, 1) {
+		assert.Equal(t, openapi.Operation("create"), otherEdits[0].Operation)
+		assert.Equal(t, "/restconf/data/ietf-network:networks/network=n1/node=b", otherEdits[0].Target)
+	}
+	// the first notification of the originator is the one of the change made by the other client
+	if assert.Len(t, originatorEdits, 1) {
+		assert.Equal(t, openapi.Operation("delete"), originatorEdits[0].Operation)
+		assert.Equal(t, "/restconf/data/ietf-network:networks/network=n1/node=a", originatorEdits[0].Target)
+	}
+	assert.Empty(t, otherNotifications)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/routers"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi/generator"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+)
+
+// OperationResponse describes the response of an RPC or an action, configured by the path of the operation.
+// The body is read from File, or produced by Template. Otherwise, the output is generated from the output schema
+// of the operation. StatusCode overrides the status code of the response, 204 leaving the body out.
+type OperationResponse struct {
+	StatusCode int
+	File       string
+	// Template is a JSON document written with text/template. It is executed with the operationTemplateData,
+	// and can call "datastore" with a JSONPath to get the values of the datastore, and "json" to encode values.
+	Template string
+}
+
+// operationTemplateData holds the values a response template can reference.
+type operationTemplateData struct {
+	// Operation is the module-qualified name of the operation
+	Operation string
+	// Input holds the children of the "input" node of the request
+	Input map[string]interface{}
+	// Instance holds the content of the data node an action is invoked on
+	Instance interface{}
+}
+
+// operationResponse looks for the response configured for the operation, by the request path or the path of its route.
+func (handler *responseGeneratorHandler) operationResponse(request *http.Request, route *routers.Route) (OperationResponse, bool) {
+	if configured, ok := handler.operations[request.URL.Path]; ok {
+		return configured, true
+	}
+	configured, ok := handler.operations[route.Path]
+	return configured, ok
+}
+
+// respondToOperation turns the generated response of the invoked operation into the configured one, if any.
+// Operations without output answer with 204.
+func (handler *responseGeneratorHandler) respondToOperation(request *http.Request, route *routers.Route, invocation *operationInvocation, db *database.Database, response *generator.Response) error {
+	configured, ok := handler.operationResponse(request, route)
+	if ok {
+		var body []byte
+		var err error
+		switch {
+		case configured.File != "":
+			body, err = ioutil.ReadFile(configured.File)
+		case configured.Template != "":
+			body, err = executeOperationTemplate(configured.Template, invocation, db)
+		}
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			response.Data = nil
+			err = json.Unmarshal(body, &response.Data)
+			if err != nil {
+				return errors.WithMessage(err, "Invalid response of the operation '"+invocation.name+"'")
+			}
+			response.StatusCode = http.StatusOK
+			response.ContentType = yangDataJSON
+		}
+		if configured.StatusCode != 0 {
+			response.StatusCode = configured.StatusCode
+		}
+	}
+	if response.Data == nil || response.StatusCode == http.StatusNoContent {
+		response.StatusCode = http.StatusNoContent
+		response.Data = nil
+	}
+	return nil
+}
+
+func executeOperationTemplate(text string, invocation *operationInvocation, db *database.Database) ([]byte, error) {
+	responseTemplate, err := template.New(invocation.name).Funcs(template.FuncMap{
+		"datastore": func(path string) (interface{}, error) {
+			return datastoreValue(db, path)
+		},
+		"json": func(value interface{}) (string, error) {
+			marshal, err := json.Marshal(value)
+			return string(marshal), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	err = responseTemplate.Execute(&body, operationTemplateData{
+		Operation: invocation.name,
+		Input:     invocation.input,
+		Instance:  invocation.instanceData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// datastoreValue returns the value of the node of the datastore selected by the JSONPath,
+// the values of all the selected nodes when there are several, and nil when there is none.
+func datastoreValue(db *database.Database, path string) (interface{}, error) {
+	nodes, err := db.Content.JSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 1 {
+		return nodes[0].Unpack()
+	}
+	var values []interface{}
+	for _, node := range nodes {
+		value, err := node.Unpack()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
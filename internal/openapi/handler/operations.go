@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/exgphe/kin-openapi/routers"
+	"github.com/muonsoft/openapi-mock/database"
+	"net/http"
+	"strings"
+)
+
+const (
+	restconfOperationsPrefix = "/restconf/operations/"
+	actionExtension          = "x-action"
+)
+
+// operationInvocation describes the invocation of an RPC or of a YANG 1.1 action (RFC 8040 section 3.6).
+type operationInvocation struct {
+	// name is the module-qualified name of the operation, such as "ietf-te:tunnel-action"
+	name string
+	// input holds the children of the "input" node of the request body
+	input map[string]interface{}
+	// instance is the key path of the data node the action is invoked on, it is empty for RPCs
+	instance database.KeyPath
+	// instanceData holds the content of the data node the action is invoked on
+	instanceData interface{}
+}
+
+// isAction tells whether the route invokes an action on a data resource rather than creating a data resource.
+// Actions are flagged by the "x-action" extension of the operation. Lacking it, an operation is taken for an action
+// when its request body holds the "input" node and its response the "output" node, since the creation of a data
+// node named "input" or "output" has one of them at most.
+func isAction(route *routers.Route) bool {
+	if route.Method != http.MethodPost || !strings.HasPrefix(route.Path, restconfDataPath+"/") {
+		return false
+	}
+	if value, ok := route.Operation.Extensions[actionExtension]; ok {
+		flag := true
+		if raw, isRaw := value.(json.RawMessage); isRaw {
+			_ = json.Unmarshal(raw, &flag)
+		}
+		return flag
+	}
+	requestBody := route.Operation.RequestBody
+	hasInput := requestBody != nil && requestBody.Value != nil && hasOperationNode(requestDataSchema(route), "input")
+	return hasInput && hasOperationNode(responseDataSchema(route), "output")
+}
+
+// hasOperationNode tells whether the schema describes an object whose only member is the input or output node of an operation.
+func hasOperationNode(schema *openapi3.Schema, node string) bool {
+	if schema == nil || len(schema.Properties) != 1 {
+		return false
+	}
+	for name := range schema.Properties {
+		return database.LocalName(name) == node
+	}
+	return false
+}
+
+// isOperationInvocation tells whether the request invokes an RPC or an action.
+func isOperationInvocation(request *http.Request, route *routers.Route) bool {
+	return strings.HasPrefix(request.URL.Path, restconfOperationsPrefix) || isAction(route)
+}
+
+// newOperationInvocation gathers the input of the operation invoked by the request, along with the data node
+// an action is invoked on. The data node is nil when the action is invoked on a data node which does not exist.
+func newOperationInvocation(request *http.Request, route *routers.Route, bodyData []byte, db *database.Database) (*operationInvocation, error) {
+	path := strings.TrimSuffix(request.URL.Path, "/")
+	invocation := &operationInvocation{name: path[strings.LastIndex(path, "/")+1:]}
+	if len(bodyData) > 0 {
+		var body map[string]interface{}
+		err := json.Unmarshal(bodyData, &body)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range body {
+			if database.LocalName(name) == "input" {
+				invocation.input, _ = value.(map[string]interface{})
+			}
+		}
+	}
+	if strings.HasPrefix(path, restconfOperationsPrefix) {
+		return invocation, nil
+	}
+
+	instance, err := database.RestconfPathToKeyPath(path[:strings.LastIndex(path, "/")], route.Operation)
+	if err != nil {
+		return nil, err
+	}
+	invocation.instance = instance
+	entry, _, err := db.Get(instance)
+	if err != nil {
+		return invocation, nil
+	}
+	err = json.Unmarshal(entry.Source(), &invocation.instanceData)
+	if err != nil {
+		return nil, err
+	}
+	return invocation, nil
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/exgphe/kin-openapi/routers"
+	"github.com/stretchr/testify/assert"
+)
+
+func nodeSchema(name string) *openapi3.Schema {
+	return &openapi3.Schema{Type: "object", Properties: openapi3.Schemas{
+		name: openapi3.NewSchemaRef("", &openapi3.Schema{Type: "object"}),
+	}}
+}
+
+func postRoute(requestSchema *openapi3.Schema, responseSchema *openapi3.Schema) *routers.Route {
+	operation := &openapi3.Operation{
+		RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+			WithContent(openapi3.NewContentWithSchema(requestSchema, []string{"application/yang-data+json"}))},
+		Responses: openapi3.NewResponses(),
+	}
+	if responseSchema != nil {
+		operation.Responses["200"] = &openapi3.ResponseRef{Value: openapi3.NewResponse().
+			WithContent(openapi3.NewContentWithSchema(responseSchema, []string{"application/yang-data+json"}))}
+	}
+	return &routers.Route{Method: http.MethodPost, Path: "/restconf/data/ex:top", Operation: operation}
+}
+
+func TestIsAction_InputAndOutputNodes_Action(t *testing.T) {
+	route := postRoute(nodeSchema("ex:input"), nodeSchema("ex:output"))
+
+	assert.True(t, isAction(route))
+}
+
+func TestIsAction_CreationOfNodeNamedInput_NotAction(t *testing.T) {
+	route := postRoute(nodeSchema("ex:input"), nil)
+
+	assert.False(t, isAction(route))
+}
+
+func TestIsAction_FlaggedByExtension_Action(t *testing.T) {
+	route := postRoute(nodeSchema("ex:reset"), nil)
+	route.Operation.Extensions = map[string]interface{}{actionExtension: true}
+
+	assert.True(t, isAction(route))
+}
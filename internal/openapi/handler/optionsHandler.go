@@ -1,9 +1,10 @@
 package handler
 
 import (
-	"encoding/json"
+	"github.com/exgphe/kin-openapi/routers"
 	"github.com/exgphe/kin-openapi/routers/legacy"
 	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/pkg/errors"
 	"net/http"
 	"strings"
 )
@@ -48,7 +49,7 @@ func (handler *optionsHandler) respond(writer http.ResponseWriter, request *http
 		}
 	}
 	if acceptPatch {
-		writer.Header().Set("Accept-Patch", "application/yang-data+json; charset=UTF-8")
+		writer.Header().Set("Accept-Patch", "application/yang-data+json; charset=UTF-8, "+yangDataXML+", "+yangPatchMediaType)
 	}
 	writer.WriteHeader(http.StatusOK)
 }
@@ -58,11 +59,25 @@ func (handler *optionsHandler) getAllowedMethods(request *http.Request) []string
 	possibleMethods := []string{"HEAD", "GET", "POST", "PUT", "PATCH", "DELETE"}
 	// temporary solution until new routing based on patterns
 	originalMethod := request.Method
+	_, _, isStream := subscriptionStream(request.URL.Path)
 	for _, method := range possibleMethods {
 		request.Method = method
 		var err error
-		if (strings.HasPrefix(request.URL.Path, "/internal/trigger") || strings.HasPrefix(request.URL.Path, "/restconf/streams/yang-push-json/subscription-id=")) && method == "GET" {
+		if (strings.HasPrefix(request.URL.Path, "/internal/trigger") || isSubscriptionStatePath(request.URL.Path) || isStream) && method == "GET" {
 			err = nil
+		} else if (isStateResource(request.URL.Path) || isDatastoreRoot(request)) && (method == "GET" || method == "HEAD") {
+			err = nil
+		} else if isNetconfOperation(request.URL.Path) {
+			if method != "POST" {
+				err = errors.New("operations are invoked with POST")
+			}
+		} else if datastore, ok := requestDatastore(request); ok && datastore.readOnly() && method != "GET" && method != "HEAD" {
+			// the intended and operational datastores cannot be written, the actions of their nodes are invoked all the same
+			var route *routers.Route
+			route, _, err = (*handler.router).FindRoute(request)
+			if err == nil && !isOperationInvocation(request, route) {
+				err = errors.New("read-only datastore")
+			}
 		} else {
 			_, _, err = (*handler.router).FindRoute(request)
 		}
@@ -75,9 +90,6 @@ func (handler *optionsHandler) getAllowedMethods(request *http.Request) []string
 }
 
 func (handler *optionsHandler) methodNotAllowed(writer http.ResponseWriter, request *http.Request) {
-	writer.Header().Set("Content-Type", "application/yang-data+json; charset=UTF-8")
-	writer.WriteHeader(http.StatusMethodNotAllowed)
-
 	restconfErrors := openapi.NewRestconfErrors(openapi.RestconfError{
 		ErrorType:    openapi.ErrorTypeProtocol,
 		ErrorTag:     openapi.ErrorTagOperationNotSuported,
@@ -85,15 +97,10 @@ func (handler *optionsHandler) methodNotAllowed(writer http.ResponseWriter, requ
 		ErrorMessage: "Method Not Allowed",
 	})
 
-	marshal, _ := json.Marshal(restconfErrors)
-
-	_, _ = writer.Write(marshal)
+	writeRestconfErrors(writer, request, http.StatusMethodNotAllowed, restconfErrors, nil)
 }
 
 func (handler *optionsHandler) notFound(writer http.ResponseWriter, request *http.Request) {
-	writer.Header().Set("Content-Type", "application/yang-data+json; charset=UTF-8")
-	writer.WriteHeader(http.StatusNotFound)
-
 	restconfErrors := openapi.NewRestconfErrors(openapi.RestconfError{
 		ErrorType:    openapi.ErrorTypeProtocol,
 		ErrorTag:     openapi.ErrorTagInvalidValue,
@@ -101,7 +108,5 @@ func (handler *optionsHandler) notFound(writer http.ResponseWriter, request *htt
 		ErrorMessage: "Not Found",
 	})
 
-	marshal, _ := json.Marshal(restconfErrors)
-
-	_, _ = writer.Write(marshal)
+	writeRestconfErrors(writer, request, http.StatusNotFound, restconfErrors, nil)
 }
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/exgphe/kin-openapi/routers/legacy"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDatastoreOptionsHandler(t *testing.T, served *bool) http.Handler {
+	action := openapi3.NewOperation()
+	action.Responses = openapi3.NewResponses()
+	action.Extensions = map[string]interface{}{actionExtension: json.RawMessage(`true`)}
+	creation := openapi3.NewOperation()
+	creation.Responses = openapi3.NewResponses()
+	retrieval := openapi3.NewOperation()
+	retrieval.Responses = openapi3.NewResponses()
+	router, err := legacy.NewRouter(&openapi3.T{OpenAPI: "3.0.0", Info: &openapi3.Info{Title: "test", Version: "1"}, Paths: openapi3.Paths{
+		"/restconf/data/ex:server":          &openapi3.PathItem{Get: retrieval, Post: creation},
+		"/restconf/data/ex:server/ex:reset": &openapi3.PathItem{Post: action},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &datastoreHandler{nextHandler: &optionsHandler{router: router, nextHandler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		*served = true
+	})}}
+}
+
+func TestOptionsHandler_ServeHTTP_ActionOnOperationalDatastore_PassedToNextHandler(t *testing.T) {
+	served := false
+	handler := newDatastoreOptionsHandler(t, &served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/restconf/ds/ietf-datastores:operational/ex:server/ex:reset", nil))
+
+	assert.True(t, served)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestOptionsHandler_ServeHTTP_CreationOnOperationalDatastore_MethodNotAllowed(t *testing.T) {
+	served := false
+	handler := newDatastoreOptionsHandler(t, &served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/restconf/ds/ietf-datastores:operational/ex:server", nil))
+
+	assert.False(t, served)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestOptionsHandler_ServeHTTP_CreationOnRunningDatastore_PassedToNextHandler(t *testing.T) {
+	served := false
+	handler := newDatastoreOptionsHandler(t, &served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/restconf/ds/ietf-datastores:running/ex:server", nil))
+
+	assert.True(t, served)
+}
+
+func TestOptionsHandler_ServeHTTP_SuspendSubscription_PassedToNextHandler(t *testing.T) {
+	served := false
+	handler := newDatastoreOptionsHandler(t, &served)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, suspendSubscriptionPath+"?id=1", nil))
+
+	assert.True(t, served)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// evaluatePreconditions evaluates the conditional request headers (RFC 7232 section 6) against the target resource.
+// It returns the status code to answer with when a precondition fails, or zero when the request may proceed.
+func evaluatePreconditions(request *http.Request, metadata database.ResourceMetadata, exists bool) int {
+	retrieval := isRetrieval(request)
+	lastModified := metadata.LastModified.Truncate(time.Second)
+
+	if ifMatch := request.Header.Get("If-Match"); ifMatch != "" {
+		if !exists || !matchesEntityTag(ifMatch, metadata.ETag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := httpDate(request.Header.Get("If-Unmodified-Since")); ok && exists {
+		if lastModified.After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if exists && matchesEntityTag(ifNoneMatch, metadata.ETag, true) {
+			if retrieval {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := httpDate(request.Header.Get("If-Modified-Since")); ok && exists && retrieval {
+		if !lastModified.After(since) {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// matchesEntityTag tells whether the entity tag is part of the list of a If-Match or If-None-Match header.
+// The weak comparison ignores the weakness indicator of the tags.
+func matchesEntityTag(header string, eTag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if eTag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == strings.TrimPrefix(eTag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func httpDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	date, err := http.ParseTime(value)
+	return date, err == nil
+}
+
+func (handler *responseGeneratorHandler) writePreconditionResult(writer http.ResponseWriter, request *http.Request, statusCode int, metadata database.ResourceMetadata) {
+	if statusCode == http.StatusNotModified {
+		writer.Header().Set("Last-Modified", metadata.LastModified.Format(http.TimeFormat))
+		writer.Header().Set("ETag", metadata.ETag)
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+	handler.writeError(writer, request,
+		http.StatusPreconditionFailed,
+		openapi.NewRestconfErrors(openapi.RestconfError{
+			ErrorType:    openapi.ErrorTypeProtocol,
+			ErrorTag:     openapi.ErrorTagOperationFailed,
+			ErrorPath:    request.URL.Path,
+			ErrorMessage: "Precondition Failed",
+		}))
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatePreconditions_GivenHeaders_ExpectedStatusCode(t *testing.T) {
+	lastModified := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	metadata := database.ResourceMetadata{ETag: `"abc"`, LastModified: lastModified}
+	before := lastModified.Add(-time.Hour).Format(http.TimeFormat)
+	after := lastModified.Add(time.Hour).Format(http.TimeFormat)
+	tests := []struct {
+		name     string
+		method   string
+		header   string
+		value    string
+		exists   bool
+		expected int
+	}{
+		{"if-match any on existing resource", http.MethodPut, "If-Match", "*", true, 0},
+		{"if-match any on missing resource", http.MethodPut, "If-Match", "*", false, http.StatusPreconditionFailed},
+		{"if-match same tag", http.MethodPut, "If-Match", `"xyz", "abc"`, true, 0},
+		{"if-match other tag", http.MethodPut, "If-Match", `"xyz"`, true, http.StatusPreconditionFailed},
+		{"if-match weak tag", http.MethodPut, "If-Match", `W/"abc"`, true, http.StatusPreconditionFailed},
+		{"if-none-match any on retrieval", http.MethodGet, "If-None-Match", "*", true, http.StatusNotModified},
+		{"if-none-match any on creation", http.MethodPut, "If-None-Match", "*", true, http.StatusPreconditionFailed},
+		{"if-none-match any on missing resource", http.MethodPut, "If-None-Match", "*", false, 0},
+		{"if-none-match weak tag on retrieval", http.MethodGet, "If-None-Match", `W/"abc"`, true, http.StatusNotModified},
+		{"if-none-match weak tag on write", http.MethodDelete, "If-None-Match", `W/"abc"`, true, http.StatusPreconditionFailed},
+		{"if-none-match other tag", http.MethodGet, "If-None-Match", `"xyz"`, true, 0},
+		{"if-modified-since before on retrieval", http.MethodGet, "If-Modified-Since", before, true, 0},
+		{"if-modified-since after on retrieval", http.MethodGet, "If-Modified-Since", after, true, http.StatusNotModified},
+		{"if-modified-since after on write", http.MethodPut, "If-Modified-Since", after, true, 0},
+		{"if-unmodified-since before", http.MethodPut, "If-Unmodified-Since", before, true, http.StatusPreconditionFailed},
+		{"if-unmodified-since after", http.MethodPut, "If-Unmodified-Since", after, true, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, "/restconf/data/ex:top", nil)
+			request.Header.Set(test.header, test.value)
+
+			statusCode := evaluatePreconditions(request, metadata, test.exists)
+
+			assert.Equal(t, test.expected, statusCode)
+		})
+	}
+}
+
+func TestEvaluatePreconditions_SiblingModified_TagStillMatches(t *testing.T) {
+	db := &database.Database{Content: ajson.Must(ajson.Unmarshal([]byte(`{"ex:top":{"x":1,"y":2}}`)))}
+	_ = db.Modified()
+	metadata, _ := db.Metadata(`$["ex:top"]["y"]`)
+	_, err := db.Put(`$["ex:top"]["x"]`, ajson.NumericNode("", 3), database.Insertion{})
+	metadataAfterEdit, _ := db.Metadata(`$["ex:top"]["y"]`)
+	request := httptest.NewRequest(http.MethodPut, "/restconf/data/ex:top/y", nil)
+	request.Header.Set("If-Match", metadata.ETag)
+	request.Header.Set("If-Unmodified-Since", metadata.LastModified.Format(http.TimeFormat))
+
+	statusCode := evaluatePreconditions(request, metadataAfterEdit, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, statusCode)
+}
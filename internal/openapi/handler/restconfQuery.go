@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"net/http"
+	"strconv"
+)
+
+const maxDepth = 65535
+
+var restconfQueryParameters = []string{"content", "depth", "fields", "filter", "insert", "point", "start-time", "stop-time", "with-defaults"}
+
+// restconfQuery holds the RESTCONF query parameters (RFC 8040 section 4.8) of a request.
+type restconfQuery struct {
+	depth        int // 0 stands for unbounded
+	fields       database.Fields
+	content      string
+	withDefaults string
+	insert       string
+	point        string
+}
+
+// parseRestconfQuery reads the query parameters of the request, the omitted ones taking their default values.
+func parseRestconfQuery(request *http.Request) (query restconfQuery, restconfError *openapi.RestconfError) {
+	query.content = database.ContentAll
+	// the basic mode advertised in the capabilities of the server
+	query.withDefaults = database.WithDefaultsExplicit
+	values := request.URL.Query()
+	for _, name := range restconfQueryParameters {
+		if len(values[name]) > 1 {
+			err := openapi.InvalidQueryParameterError(name, values[name][1])
+			err.ErrorMessage = "Query parameter '" + name + "' must not appear more than once"
+			return query, &err
+		}
+	}
+
+	if values.Has("content") {
+		content := values.Get("content")
+		if !isRetrieval(request) || (content != database.ContentAll && content != database.ContentConfig && content != database.ContentNonconfig) {
+			return query, queryParameterError("content", content)
+		}
+		query.content = content
+	}
+
+	if values.Has("with-defaults") {
+		withDefaults := values.Get("with-defaults")
+		switch withDefaults {
+		case database.WithDefaultsExplicit, database.WithDefaultsReportAll, database.WithDefaultsReportAllTagged, database.WithDefaultsTrim:
+		default:
+			return query, queryParameterError("with-defaults", withDefaults)
+		}
+		if !isRetrieval(request) {
+			return query, queryParameterError("with-defaults", withDefaults)
+		}
+		query.withDefaults = withDefaults
+	}
+
+	if values.Has("depth") {
+		if !isRetrieval(request) {
+			return query, queryParameterError("depth", values.Get("depth"))
+		}
+		query.depth, restconfError = parseDepth(values.Get("depth"))
+		if restconfError != nil {
+			return
+		}
+	}
+
+	if values.Has("fields") {
+		if !isRetrieval(request) {
+			return query, queryParameterError("fields", values.Get("fields"))
+		}
+		fields, err := database.ParseFields(values.Get("fields"))
+		if err != nil {
+			restconfError = queryParameterError("fields", values.Get("fields"))
+			restconfError.ErrorMessage = err.Error()
+			return
+		}
+		query.fields = fields
+	}
+
+	if values.Has("insert") {
+		insert := values.Get("insert")
+		switch insert {
+		case database.InsertFirst, database.InsertLast, database.InsertBefore, database.InsertAfter:
+		default:
+			return query, queryParameterError("insert", insert)
+		}
+		if !isCreation(request) {
+			return query, queryParameterError("insert", insert)
+		}
+		query.insert = insert
+	}
+
+	if values.Has("point") {
+		if query.insert != database.InsertBefore && query.insert != database.InsertAfter {
+			err := openapi.BadAttributeError("point", "The 'point' query parameter requires 'insert' to be 'before' or 'after'")
+			return query, &err
+		}
+		query.point = values.Get("point")
+	} else if query.insert == database.InsertBefore || query.insert == database.InsertAfter {
+		err := openapi.MissingAttributeError("point", "The 'point' query parameter is required when 'insert' is '"+query.insert+"'")
+		return query, &err
+	}
+
+	return query, nil
+}
+
+// insertion resolves the "insert" and "point" parameters against the list named listName described by list.
+// They are only allowed on the lists and leaf-lists which are ordered-by user.
+func (query restconfQuery) insertion(listName string, list *openapi3.Schema) (database.Insertion, *openapi.RestconfError) {
+	insertion := database.Insertion{Where: query.insert}
+	if query.insert != "" && !database.IsOrderedByUser(list) {
+		err := openapi.BadAttributeError("insert", "The 'insert' query parameter requires '"+listName+"' to be ordered-by user")
+		return insertion, &err
+	}
+	if query.point == "" {
+		return insertion, nil
+	}
+	listKeys := database.ListKeys(list)
+	point, err := database.ParsePoint(query.point, listName, listKeys)
+	if err != nil {
+		restconfError := openapi.BadAttributeError("point", "The 'point' query parameter is invalid: "+err.Error())
+		return insertion, &restconfError
+	}
+	insertion.Point = point
+	return insertion, nil
+}
+
+func parseDepth(value string) (int, *openapi.RestconfError) {
+	if value == "unbounded" {
+		return 0, nil
+	}
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 || depth > maxDepth {
+		return 0, queryParameterError("depth", value)
+	}
+	return depth, nil
+}
+
+func isRetrieval(request *http.Request) bool {
+	return request.Method == http.MethodGet || request.Method == http.MethodHead
+}
+
+func isCreation(request *http.Request) bool {
+	return request.Method == http.MethodPost || request.Method == http.MethodPut
+}
+
+func isWrite(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func queryParameterError(name string, value string) *openapi.RestconfError {
+	err := openapi.InvalidQueryParameterError(name, value)
+	return &err
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRetrievalQuery_GetWithoutQueryParameters_ConfigAndStateKept(t *testing.T) {
+	schema := &openapi3.Schema{Type: "object", Properties: openapi3.Schemas{
+		"ex:top": openapi3.NewSchemaRef("", &openapi3.Schema{Type: "object", Properties: openapi3.Schemas{
+			"cf": openapi3.NewSchemaRef("", openapi3.NewStringSchema()),
+			"st": openapi3.NewSchemaRef("", &openapi3.Schema{Type: "string", ExtensionProps: openapi3.ExtensionProps{
+				Extensions: map[string]interface{}{"x-config": false},
+			}}),
+		}}),
+	}}
+	data := map[string]interface{}{"ex:top": map[string]interface{}{"cf": "a", "st": "b"}}
+	query, restconfError := parseRestconfQuery(httptest.NewRequest(http.MethodGet, "/restconf/data/ex:top", nil))
+
+	withSchema, err := applyRetrievalQuery(data, query, schema)
+	withoutSchema, errWithoutSchema := applyRetrievalQuery(data, query, nil)
+
+	assert.Nil(t, restconfError)
+	assert.NoError(t, err)
+	assert.Equal(t, data, withSchema)
+	assert.NoError(t, errWithoutSchema)
+	assert.Equal(t, data, withoutSchema)
+}
+
+func TestApplyRetrievalQuery_GetWithoutWithDefaults_DefaultsNotReported(t *testing.T) {
+	schema := &openapi3.Schema{Type: "object", Properties: openapi3.Schemas{
+		"ex:player": openapi3.NewSchemaRef("", &openapi3.Schema{Type: "object", Properties: openapi3.Schemas{
+			"volume": openapi3.NewSchemaRef("", openapi3.NewIntegerSchema().WithDefault(10)),
+		}}),
+	}}
+	data := map[string]interface{}{"ex:player": map[string]interface{}{}}
+	query, restconfError := parseRestconfQuery(httptest.NewRequest(http.MethodGet, "/restconf/data/ex:player", nil))
+
+	retrieved, err := applyRetrievalQuery(data, query, schema)
+
+	assert.Nil(t, restconfError)
+	assert.NoError(t, err)
+	assert.Equal(t, data, retrieved)
+}
+
+func listSchema(orderedBy string) *openapi3.Schema {
+	extensions := map[string]interface{}{"x-key": json.RawMessage(`"name"`)}
+	if orderedBy != "" {
+		extensions["x-ordered-by"] = json.RawMessage(`"` + orderedBy + `"`)
+	}
+	return &openapi3.Schema{Type: "array", ExtensionProps: openapi3.ExtensionProps{Extensions: extensions}}
+}
+
+func TestRestconfQuery_Insertion_OrderedByUserList_PointResolved(t *testing.T) {
+	query, restconfError := parseRestconfQuery(httptest.NewRequest(http.MethodPost, "/restconf/data/ex:songs?insert=after&point=/ex:songs=one", nil))
+
+	insertion, err := query.insertion("ex:songs", listSchema("user"))
+
+	assert.Nil(t, restconfError)
+	assert.Nil(t, err)
+	assert.Equal(t, database.Insertion{Where: database.InsertAfter, Point: map[string]string{"name": "one"}}, insertion)
+}
+
+func TestRestconfQuery_Insertion_OrderedBySystemList_BadAttributeError(t *testing.T) {
+	for _, orderedBy := range []string{"", "system"} {
+		t.Run(orderedBy, func(t *testing.T) {
+			query, restconfError := parseRestconfQuery(httptest.NewRequest(http.MethodPost, "/restconf/data/ex:songs?insert=first", nil))
+
+			_, err := query.insertion("ex:songs", listSchema(orderedBy))
+
+			assert.Nil(t, restconfError)
+			if assert.NotNil(t, err) {
+				assert.Equal(t, openapi.ErrorTagBadAttribute, err.ErrorTag)
+				assert.Equal(t, map[string]string{"bad-attribute": "insert"}, err.ErrorInfo)
+			}
+		})
+	}
+}
+
+func TestRestconfQuery_Insertion_WithoutInsert_NoInsertion(t *testing.T) {
+	query, restconfError := parseRestconfQuery(httptest.NewRequest(http.MethodPost, "/restconf/data/ex:songs", nil))
+
+	insertion, err := query.insertion("ex:songs", listSchema(""))
+
+	assert.Nil(t, restconfError)
+	assert.Nil(t, err)
+	assert.Equal(t, database.Insertion{}, insertion)
+}
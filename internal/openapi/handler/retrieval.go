@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/exgphe/kin-openapi/routers"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/spyzhov/ajson"
+	"net/http"
+)
+
+// applyRetrievalQuery shapes the data of a GET response according to the retrieval query parameters.
+// The data is expected to be wrapped in its module-qualified top level key.
+// The schema describes the wrapping object and is used for default values and filtering by content.
+func applyRetrievalQuery(data interface{}, query restconfQuery, schema *openapi3.Schema) (interface{}, error) {
+	if data == nil || (query.depth == 0 && query.fields == nil && query.content == database.ContentAll && query.withDefaults == database.WithDefaultsExplicit) {
+		return data, nil
+	}
+	root, err := dataToNode(data)
+	if err != nil {
+		return nil, err
+	}
+	if !root.IsObject() {
+		return data, nil
+	}
+	for _, key := range root.Keys() {
+		child, err := root.GetKey(key)
+		if err != nil {
+			return nil, err
+		}
+		childSchema := database.ChildSchema(schema, key)
+		err = database.ApplyWithDefaults(child, childSchema, query.withDefaults)
+		if err != nil {
+			return nil, err
+		}
+		err = database.FilterContent(child, childSchema, query.content)
+		if err != nil {
+			return nil, err
+		}
+		depthRoots := []*ajson.Node{child}
+		if query.fields != nil {
+			depthRoots, err = database.ProjectFields(child, query.fields)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if query.depth == 0 {
+			continue
+		}
+		for _, depthRoot := range depthRoots {
+			err = database.PruneDepth(depthRoot, query.depth)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return root.Unpack()
+}
+
+func dataToNode(data interface{}) (*ajson.Node, error) {
+	marshal, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return ajson.Unmarshal(marshal)
+}
+
+// responseDataSchema returns the schema of the successful response of a data resource, if any.
+func responseDataSchema(route *routers.Route) *openapi3.Schema {
+	return operationDataSchema(route.Operation)
+}
+
+// operationDataSchema returns the schema of the successful response of an operation, if any.
+func operationDataSchema(operation *openapi3.Operation) *openapi3.Schema {
+	response := operation.Responses.Get(http.StatusOK)
+	if response == nil || response.Value == nil {
+		return nil
+	}
+	mediaType := response.Value.Content.Get("application/yang-data+json")
+	if mediaType == nil {
+		for _, candidate := range response.Value.Content {
+			mediaType = candidate
+			break
+		}
+	}
+	if mediaType == nil || mediaType.Schema == nil {
+		return nil
+	}
+	return mediaType.Schema.Value
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	yangLibraryPath          = "/restconf/data/ietf-yang-library:yang-library"
+	restconfStatePath        = "/restconf/data/ietf-restconf-monitoring:restconf-state"
+	restconfCapabilitiesPath = restconfStatePath + "/capabilities"
+	restconfStreamsPath      = restconfStatePath + "/streams"
+	yangPushJSONStreamPath   = "/restconf/streams/yang-push-json"
+	yangPushXMLStreamPath    = "/restconf/streams/yang-push-xml"
+	subscriptionIDPrefix     = "/subscription-id="
+)
+
+// builtinModules are the modules implemented by the mock itself, whatever the loaded specification.
+var builtinModules = []openapi.YangLibraryModule{
+	{Name: "ietf-datastores", Revision: "2018-02-14"},
+	{Name: "ietf-netconf", Revision: "2011-06-01"},
+	{Name: "ietf-restconf", Revision: "2017-01-26"},
+	{Name: "ietf-restconf-monitoring", Revision: "2017-01-26"},
+	{Name: "ietf-subscribed-notifications", Revision: "2019-09-09"},
+	{Name: "ietf-yang-library", Revision: openapi.YangLibraryVersion},
+	{Name: "ietf-yang-patch", Revision: "2017-02-22"},
+	{Name: "ietf-yang-push", Revision: "2019-09-09"},
+}
+
+func isStateResource(path string) bool {
+	switch path {
+	case yangLibraryPath, restconfStatePath, restconfCapabilitiesPath, restconfStreamsPath:
+		return true
+	}
+	return false
+}
+
+// stateResource synthesizes the data of the state resource at the request path,
+// which describes the loaded specification and the features of the mock rather than the datastore.
+func (handler *responseGeneratorHandler) stateResource(request *http.Request) interface{} {
+	switch request.URL.Path {
+	case yangLibraryPath:
+		return openapi.NewYangLibrary(handler.modules).Wrap()
+	case restconfStatePath:
+		return openapi.RestconfState{
+			Capabilities: openapi.NewRestconfCapabilities(),
+			Streams:      restconfStreams(request),
+		}.Wrap()
+	case restconfCapabilitiesPath:
+		return openapi.NewRestconfCapabilities().Wrap()
+	case restconfStreamsPath:
+		return restconfStreams(request).Wrap()
+	}
+	return nil
+}
+
+func restconfStreams(request *http.Request) openapi.RestconfStreams {
+	scheme := "http"
+	if request.TLS != nil {
+		scheme = "https"
+	}
+	stream := openapi.RestconfStream{
+		Name:        "yang-push",
+		Description: "Datastore updates of the subscriptions established with ietf-subscribed-notifications:establish-subscription",
+		Access: []openapi.RestconfStreamAccess{
+			{Encoding: "json", Location: scheme + "://" + request.Host + yangPushJSONStreamPath},
+			{Encoding: "xml", Location: scheme + "://" + request.Host + yangPushXMLStreamPath},
+		},
+	}
+	if replaySupport, creationTime := subscriptionCenter.ReplayLog(); replaySupport {
+		stream.ReplaySupport = true
+		stream.ReplayLogCreationTime = openapi.FormatEventTime(creationTime)
+	}
+	return openapi.RestconfStreams{Stream: []openapi.RestconfStream{stream}}
+}
+
+// subscriptionStream tells whether the path is the stream of a subscription, returning the encoding of its
+// notifications and the id of the subscription.
+func subscriptionStream(path string) (encoding string, id string, ok bool) {
+	if id := strings.TrimPrefix(path, yangPushJSONStreamPath+subscriptionIDPrefix); id != path {
+		return openapi.EncodeJSON, id, true
+	}
+	if id := strings.TrimPrefix(path, yangPushXMLStreamPath+subscriptionIDPrefix); id != path {
+		return openapi.EncodeXML, id, true
+	}
+	return "", "", false
+}
+
+// specModules collects the modules prefixing the nodes of the data resource and operation paths of the specification,
+// along with the modules built into the mock.
+func specModules(spec *openapi3.T, namespaces yangxml.Namespaces) []openapi.YangLibraryModule {
+	modules := map[string]openapi.YangLibraryModule{}
+	for _, module := range builtinModules {
+		modules[module.Name] = module
+	}
+	if spec != nil {
+		for path := range spec.Paths {
+			var nodes string
+			if strings.HasPrefix(path, "/restconf/data/") {
+				nodes = strings.TrimPrefix(path, "/restconf/data/")
+			} else if strings.HasPrefix(path, "/restconf/operations/") {
+				nodes = strings.TrimPrefix(path, "/restconf/operations/")
+			} else {
+				continue
+			}
+			for _, segment := range strings.Split(nodes, "/") {
+				name, _, _ := strings.Cut(segment, "=")
+				module, _, qualified := strings.Cut(name, ":")
+				if _, exists := modules[module]; qualified && !exists && module != "" {
+					modules[module] = openapi.YangLibraryModule{Name: module}
+				}
+			}
+		}
+	}
+
+	result := make([]openapi.YangLibraryModule, 0, len(modules))
+	for _, module := range modules {
+		module.Namespace = namespaces.Namespace(module.Name)
+		result = append(result, module)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
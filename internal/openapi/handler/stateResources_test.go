@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+	"github.com/stretchr/testify/assert"
+)
+
+func minimalSpec(paths ...string) *openapi3.T {
+	spec := &openapi3.T{OpenAPI: "3.0.0", Info: &openapi3.Info{Title: "test", Version: "1"}, Paths: openapi3.Paths{}}
+	for _, path := range paths {
+		spec.Paths[path] = &openapi3.PathItem{}
+	}
+	return spec
+}
+
+func moduleNames(modules []openapi.YangLibraryModule) []string {
+	names := make([]string, len(modules))
+	for i, module := range modules {
+		names[i] = module.Name
+	}
+	return names
+}
+
+func TestSpecModules_NoSpec_BuiltinModulesOnly(t *testing.T) {
+	modules := specModules(nil, nil)
+
+	assert.Equal(t, moduleNames(builtinModules), moduleNames(modules))
+	for _, module := range modules {
+		assert.NotEmpty(t, module.Revision, module.Name)
+		assert.Equal(t, yangxml.Namespaces(nil).Namespace(module.Name), module.Namespace)
+	}
+}
+
+func TestSpecModules_MinimalSpec_ModulesOfDataAndOperationPathsAdded(t *testing.T) {
+	spec := minimalSpec(
+		"/restconf/data/example-jukebox:jukebox/library/artist={name}",
+		"/restconf/data/example-jukebox:jukebox/example-events:events",
+		"/restconf/operations/example-ops:reboot",
+		"/internal/trigger/other-module:node",
+	)
+	namespaces := yangxml.Namespaces{"example-jukebox": "http://example.com/ns/example-jukebox"}
+
+	modules := specModules(spec, namespaces)
+
+	expected := append([]string{"example-events", "example-jukebox", "example-ops"}, moduleNames(builtinModules)...)
+	assert.Equal(t, expected, moduleNames(modules))
+	assert.Equal(t, openapi.YangLibraryModule{Name: "example-jukebox", Namespace: "http://example.com/ns/example-jukebox"}, modules[1])
+	assert.Equal(t, openapi.YangLibraryModule{Name: "example-ops", Namespace: "urn:ietf:params:xml:ns:yang:example-ops"}, modules[2])
+}
+
+func TestSpecModules_BuiltinModuleInSpec_RevisionKept(t *testing.T) {
+	modules := specModules(minimalSpec("/restconf/data/ietf-yang-library:yang-library"), nil)
+
+	assert.Equal(t, moduleNames(builtinModules), moduleNames(modules))
+	assert.Contains(t, modules, openapi.YangLibraryModule{
+		Name:      "ietf-yang-library",
+		Revision:  openapi.YangLibraryVersion,
+		Namespace: "urn:ietf:params:xml:ns:yang:ietf-yang-library",
+	})
+}
+
+func TestNewYangLibrary_SameSpec_ContentIDStable(t *testing.T) {
+	spec := minimalSpec("/restconf/data/a:x", "/restconf/data/b:y", "/restconf/data/c:z", "/restconf/operations/d:reset")
+
+	first := openapi.NewYangLibrary(specModules(spec, nil))
+	second := openapi.NewYangLibrary(specModules(spec, nil))
+
+	assert.NotEmpty(t, first.ContentID)
+	assert.Equal(t, first.ContentID, second.ContentID)
+}
+
+func TestNewYangLibrary_ModuleAdded_ContentIDChanged(t *testing.T) {
+	before := openapi.NewYangLibrary(specModules(minimalSpec("/restconf/data/a:x"), nil))
+	after := openapi.NewYangLibrary(specModules(minimalSpec("/restconf/data/a:x", "/restconf/data/b:y"), nil))
+
+	assert.NotEqual(t, before.ContentID, after.ContentID)
+}
+
+func TestRestconfStreams_GivenRequest_LocationOnRequestHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		tls    *tls.ConnectionState
+		scheme string
+	}{
+		{"http", nil, "http"},
+		{"https", &tls.ConnectionState{}, "https"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, restconfStreamsPath, nil)
+			request.Host = "mock.example.com:8080"
+			request.TLS = test.tls
+
+			streams := restconfStreams(request)
+
+			if assert.Len(t, streams.Stream, 1) {
+				assert.Equal(t, "yang-push", streams.Stream[0].Name)
+				assert.Equal(t, []openapi.RestconfStreamAccess{
+					{Encoding: "json", Location: test.scheme + "://mock.example.com:8080/restconf/streams/yang-push-json"},
+					{Encoding: "xml", Location: test.scheme + "://mock.example.com:8080/restconf/streams/yang-push-xml"},
+				}, streams.Stream[0].Access)
+				assert.True(t, streams.Stream[0].ReplaySupport)
+				assert.NotEmpty(t, streams.Stream[0].ReplayLogCreationTime)
+			}
+		})
+	}
+}
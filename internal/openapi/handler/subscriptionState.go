@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/generator"
+	"net/http"
+	"strconv"
+)
+
+// Internal endpoints suspending and resuming the subscription given by the "id" query parameter,
+// the way a device does when it runs out of resources. The reason of a suspension is given by the "reason" parameter.
+// Like "/internal/trigger", they are invoked with GET.
+const (
+	suspendSubscriptionPath = "/internal/suspend-subscription"
+	resumeSubscriptionPath  = "/internal/resume-subscription"
+)
+
+func isSubscriptionStatePath(path string) bool {
+	return path == suspendSubscriptionPath || path == resumeSubscriptionPath
+}
+
+func (handler *responseGeneratorHandler) serveSubscriptionState(writer http.ResponseWriter, request *http.Request) {
+	id, err := strconv.ParseUint(request.URL.Query().Get("id"), 10, 32)
+	if err != nil {
+		handler.badRequestRestconf(writer, request, openapi.InvalidQueryParameterError("id", request.URL.Query().Get("id")))
+		return
+	}
+	var found bool
+	if request.URL.Path == suspendSubscriptionPath {
+		reason := request.URL.Query().Get("reason")
+		if reason == "" {
+			reason = openapi.ReasonInsufficientResources
+		}
+		found = subscriptionCenter.Suspend(uint32(id), reason)
+	} else {
+		found = subscriptionCenter.Resume(uint32(id))
+	}
+	if !found {
+		handler.notFound(writer, request)
+		return
+	}
+	handler.responder.WriteResponse(request.Context(), writer, request.URL.Path, &generator.Response{
+		StatusCode: http.StatusNoContent,
+	})
+}
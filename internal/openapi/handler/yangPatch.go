@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/exgphe/kin-openapi/routers"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/pkg/errors"
+	"github.com/spyzhov/ajson"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const yangPatchMediaType = "application/yang-patch+json"
+
+func isYangPatch(request *http.Request) bool {
+	if request.Method != http.MethodPatch {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	return err == nil && mediaType == yangPatchMediaType
+}
+
+// applyYangPatch applies the YANG Patch (RFC 8072) in bodyData to the target resource at keyPath, all edits or none of them.
+// It returns the status code of the response along with the yang-patch-status to report.
+func applyYangPatch(request *http.Request, db *database.Database, keyPath database.KeyPath, route *routers.Route, bodyData []byte) (int, openapi.YangPatchStatusWrapped) {
+	var patch openapi.YangPatch
+	err := json.Unmarshal(bodyData, &patch)
+	if err != nil {
+		return http.StatusBadRequest, openapi.NewYangPatchGlobalError("", yangPatchError(openapi.ErrorTagMalformedMessage, request.URL.Path, "Cannot extract body: "+err.Error()))
+	}
+	patchID := patch.YangPatch.PatchID
+	if len(patch.YangPatch.Edit) == 0 {
+		return http.StatusBadRequest, openapi.NewYangPatchGlobalError(patchID, yangPatchError(openapi.ErrorTagInvalidValue, request.URL.Path, "The patch contains no edit"))
+	}
+	targetSchema := database.ChildSchema(requestDataSchema(route), targetResourceName(request.URL.Path))
+
+	edits := make([]database.Edit, 0, len(patch.YangPatch.Edit))
+	for _, patchEdit := range patch.YangPatch.Edit {
+		edit, restconfError := resolveYangPatchEdit(keyPath, targetSchema, patchEdit)
+		if restconfError != nil {
+			restconfError.ErrorPath = request.URL.Path + strings.TrimSuffix(patchEdit.Target, "/")
+			return http.StatusBadRequest, openapi.NewYangPatchEditError(patchID, patchEdit.EditID, *restconfError)
+		}
+		edits = append(edits, edit)
+	}
+
+	index, err := db.ApplyEdits(edits)
+	if err != nil {
+		if index < 0 {
+			return http.StatusInternalServerError, openapi.NewYangPatchGlobalError(patchID, yangPatchError(openapi.ErrorTagOperationFailed, request.URL.Path, err.Error()))
+		}
+		patchEdit := patch.YangPatch.Edit[index]
+		errorPath := request.URL.Path + strings.TrimSuffix(patchEdit.Target, "/")
+		statusCode := http.StatusBadRequest
+		var restconfError openapi.RestconfError
+		switch err.(type) {
+		case *database.DataExistsError:
+			statusCode = http.StatusConflict
+			restconfError = yangPatchError(openapi.ErrorTagDataExists, errorPath, "Data already exists; cannot create new resource")
+		case *database.DataMissingError:
+			statusCode = http.StatusConflict
+			restconfError = yangPatchError(openapi.ErrorTagDataMissing, errorPath, "Data does not exist; cannot delete or move resource")
+		case *database.PointNotFoundError:
+			restconfError = openapi.BadAttributeError("point", "The insertion point '"+patchEdit.Point+"' does not exist")
+			restconfError.ErrorPath = errorPath
+		default:
+			restconfError = yangPatchError(openapi.ErrorTagOperationFailed, errorPath, err.Error())
+		}
+		return statusCode, openapi.NewYangPatchEditError(patchID, patchEdit.EditID, restconfError)
+	}
+	return http.StatusOK, openapi.NewYangPatchOk(patchID)
+}
+
+func resolveYangPatchEdit(keyPath database.KeyPath, targetSchema *openapi3.Schema, patchEdit openapi.YangPatchEdit) (database.Edit, *openapi.RestconfError) {
+	edit := database.Edit{Operation: string(patchEdit.Operation)}
+	var err error
+	edit.KeyPath, edit.Schema, err = database.ResolveDataPath(keyPath, targetSchema, patchEdit.Target)
+	if err != nil {
+		restconfError := openapi.InvalidValueError("", "Invalid edit target: "+err.Error())
+		return edit, &restconfError
+	}
+
+	switch patchEdit.Operation {
+	case openapi.OperationCreate, openapi.OperationInsert, openapi.OperationMerge, openapi.OperationReplace:
+		edit.Value, err = yangPatchEditValue(patchEdit.Value)
+		if err != nil {
+			restconfError := openapi.InvalidValueError("", "Invalid edit value: "+err.Error())
+			return edit, &restconfError
+		}
+		if nonconfigPath, found := database.FindNonconfig(edit.Value, edit.Schema, true); found {
+			restconfError := openapi.InvalidValueError("", "Node '"+nonconfigPath+"' is not configuration data and cannot be written")
+			return edit, &restconfError
+		}
+	case openapi.OperationDelete, openapi.OperationRemove, openapi.OperationMove:
+	default:
+		restconfError := openapi.InvalidValueError("", "Unknown edit operation '"+string(patchEdit.Operation)+"'")
+		return edit, &restconfError
+	}
+
+	if patchEdit.Operation != openapi.OperationInsert && patchEdit.Operation != openapi.OperationMove {
+		return edit, nil
+	}
+	edit.Insertion.Where = patchEdit.Where
+	switch patchEdit.Where {
+	case database.InsertFirst, database.InsertLast:
+	case database.InsertBefore, database.InsertAfter:
+		if patchEdit.Point == "" {
+			restconfError := openapi.MissingAttributeError("point", "The 'point' leaf is required when 'where' is '"+patchEdit.Where+"'")
+			return edit, &restconfError
+		}
+		listName, _, _ := strings.Cut(path.Base(strings.TrimSuffix(patchEdit.Target, "/")), "=")
+		edit.Insertion.Point, err = database.ParsePoint(patchEdit.Point, listName, database.ListKeys(edit.Schema))
+		if err != nil {
+			restconfError := openapi.BadAttributeError("point", "The 'point' leaf is invalid: "+err.Error())
+			return edit, &restconfError
+		}
+	case "":
+		edit.Insertion.Where = database.InsertLast
+	default:
+		restconfError := openapi.BadAttributeError("where", "Unknown insertion point '"+patchEdit.Where+"'")
+		return edit, &restconfError
+	}
+	return edit, nil
+}
+
+// yangPatchEditValue unwraps the value of an edit, which holds the target node under its module-qualified name.
+func yangPatchEditValue(value interface{}) (*ajson.Node, error) {
+	node, err := dataToNode(value)
+	if err != nil {
+		return nil, err
+	}
+	if !node.IsObject() || node.Size() != 1 {
+		return nil, errors.New("the value must hold exactly one node")
+	}
+	return node.GetKey(node.Keys()[0])
+}
+
+// targetResourceName returns the node name of the last segment of a data resource path, such as "song" for ".../song=1".
+func targetResourceName(resourcePath string) string {
+	name, _, _ := strings.Cut(path.Base(resourcePath), "=")
+	unescaped, err := url.PathUnescape(name)
+	if err != nil {
+		return name
+	}
+	return unescaped
+}
+
+// requestDataSchema returns the schema of the request body of a data resource, if any.
+func requestDataSchema(route *routers.Route) *openapi3.Schema {
+	requestBody := route.Operation.RequestBody
+	if requestBody == nil || requestBody.Value == nil {
+		return responseDataSchema(route)
+	}
+	mediaType := requestBody.Value.Content.Get("application/yang-data+json")
+	if mediaType == nil || mediaType.Schema == nil {
+		return responseDataSchema(route)
+	}
+	return mediaType.Schema.Value
+}
+
+func yangPatchError(tag string, errorPath string, message string) openapi.RestconfError {
+	return openapi.RestconfError{
+		ErrorType:    openapi.ErrorTypeApplication,
+		ErrorTag:     tag,
+		ErrorPath:    errorPath,
+		ErrorMessage: message,
+	}
+}
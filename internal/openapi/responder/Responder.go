@@ -7,6 +7,7 @@ import (
 
 	"github.com/muonsoft/openapi-mock/internal/openapi/generator"
 	"github.com/muonsoft/openapi-mock/internal/openapi/responder/serializer"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
 )
 
 type Responder interface {
@@ -14,9 +15,9 @@ type Responder interface {
 	WriteError(ctx context.Context, writer http.ResponseWriter, path string, err error)
 }
 
-func New() Responder {
+func New(namespaces yangxml.Namespaces) Responder {
 	return &coordinatingResponder{
-		serializer: serializer.New(),
+		serializer: serializer.New(namespaces),
 		formatGuessers: []formatGuess{
 			{
 				format:  "json",
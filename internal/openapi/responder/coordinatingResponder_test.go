@@ -62,7 +62,7 @@ func TestWriteResponse_GivenResponse_SerializedDataWritten(t *testing.T) {
 				Return([]byte("serialized"), nil).
 				Once()
 			recorder := httptest.NewRecorder()
-			responder := New().(*coordinatingResponder)
+			responder := New(nil).(*coordinatingResponder)
 			responder.serializer = serializer
 
 			responder.WriteResponse(context.Background(), recorder, "", response)
@@ -84,7 +84,7 @@ func TestCoordinatingResponder_WriteResponse_NoContentResponse_EmptyBodyWritten(
 	serializer := &serializermock.Serializer{}
 	serializer.On("Serialize", response.Data, "raw").Return([]byte(""), nil).Once()
 	recorder := httptest.NewRecorder()
-	responder := New().(*coordinatingResponder)
+	responder := New(nil).(*coordinatingResponder)
 	responder.serializer = serializer
 
 	responder.WriteResponse(context.Background(), recorder, "", response)
@@ -107,7 +107,7 @@ func TestCoordinatingResponder_WriteResponse_SerializationError_UnexpectedErrorW
 		Return(nil, errors.New("error")).
 		Once()
 	recorder := httptest.NewRecorder()
-	responder := New().(*coordinatingResponder)
+	responder := New(nil).(*coordinatingResponder)
 	responder.serializer = serializer
 
 	responder.WriteResponse(context.Background(), recorder, "", response)
@@ -121,7 +121,7 @@ func TestCoordinatingResponder_WriteResponse_SerializationError_UnexpectedErrorW
 
 //func TestCoordinatingResponder_WriteError_UnsupportedFeatureError_UnsupportedPage(t *testing.T) {
 //	recorder := httptest.NewRecorder()
-//	responder := New()
+//	responder := New(nil)
 //	notSupported := &apperrors.NotSupported{Message: "unsupported feature description"}
 //
 //	responder.WriteError(context.Background(), recorder, notSupported)
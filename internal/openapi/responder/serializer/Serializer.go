@@ -1,15 +1,18 @@
 package serializer
 
+import "github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+
 type Serializer interface {
 	Serialize(data interface{}, format string) ([]byte, error)
 }
 
-func New() Serializer {
+// New creates the serializer of every supported format, XML elements are qualified by the given module namespaces.
+func New(namespaces yangxml.Namespaces) Serializer {
 	return &coordinatingSerializer{
 		formatSerializers: map[string]Serializer{
 			"raw":  &rawSerializer{},
 			"json": &jsonSerializer{},
-			"xml":  &xmlSerializer{rootTag: "root"},
+			"xml":  &xmlSerializer{rootTag: "root", namespaces: namespaces},
 		},
 	}
 }
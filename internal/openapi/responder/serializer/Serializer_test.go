@@ -7,7 +7,7 @@ import (
 )
 
 func TestNew(t *testing.T) {
-	serializer := New()
+	serializer := New(nil)
 
 	assert.IsType(t, &rawSerializer{}, serializer.(*coordinatingSerializer).formatSerializers["raw"])
 	assert.IsType(t, &jsonSerializer{}, serializer.(*coordinatingSerializer).formatSerializers["json"])
@@ -1,16 +1,28 @@
 package serializer
 
-import "github.com/clbanning/mxj"
+import (
+	"github.com/clbanning/mxj"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+	"reflect"
+)
 
 type xmlSerializer struct {
-	rootTag string
+	rootTag    string
+	namespaces yangxml.Namespaces
 }
 
 func (serializer *xmlSerializer) Serialize(data interface{}, format string) ([]byte, error) {
-	if object, isObject := data.(map[string]interface{}); isObject {
-		xml := mxj.Map(object)
-		return xml.Xml()
+	if isObject(data) {
+		return yangxml.Marshal(data, serializer.namespaces)
 	}
 
 	return mxj.AnyXml(data, serializer.rootTag)
 }
+
+func isObject(data interface{}) bool {
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	return value.Kind() == reflect.Map || value.Kind() == reflect.Struct
+}
@@ -3,6 +3,7 @@ package serializer
 import (
 	"testing"
 
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -47,3 +48,15 @@ func TestXmlSerializer_Serialize_SerializableData_SerializedData(t *testing.T) {
 		})
 	}
 }
+
+func TestXmlSerializer_Serialize_ModuleQualifiedData_NamespacedXml(t *testing.T) {
+	serializer := xmlSerializer{
+		rootTag:    "root",
+		namespaces: yangxml.Namespaces{"example": "http://example.com/ns/example"},
+	}
+
+	bytes, err := serializer.Serialize(map[string]interface{}{"example:top": map[string]interface{}{"leaf": 1}}, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<top xmlns="http://example.com/ns/example"><leaf>1</leaf></top>`, string(bytes))
+}
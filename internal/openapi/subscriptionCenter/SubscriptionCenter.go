@@ -4,46 +4,51 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	net "github.com/exgphe/go-sse"
+	"github.com/exgphe/kin-openapi/openapi3"
 	"github.com/google/uuid"
+	"github.com/muonsoft/openapi-mock/database"
 	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
 	"github.com/muonsoft/openapi-mock/set"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/spyzhov/ajson"
 	"io/fs"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// SubscriptionCenter holds the dynamic subscriptions and the clients connected to their streams.
+// It is safe for concurrent use. Its lock guards the maps only, and is never held while acquiring another lock.
 type SubscriptionCenter struct {
 	counter       uint32
-	subscriptions map[uint32][]openapi.ObjectTypeInfo // subscription id -> objectType[]
-	brokerMap     map[uint32]*net.Broker
-	connMap       map[uint32]map[string]*net.ClientConnection // subscription id -> connection id -> connection
+	m             sync.RWMutex
+	subscriptions map[uint32]*subscription
+	connMap       map[uint32]map[string]*connection // subscription id -> connection id -> connection
+	saveM         sync.Mutex                        // serializes the writes of the subscriptions file
+	datastore     Datastore
+	schema        *openapi3.Schema
+	eventLog      *eventLog
+	xmlNamespaces yangxml.Namespaces
 }
 
 type subscriptionCenterDTO struct {
 	Counter       uint32
-	Subscriptions map[uint32][]openapi.ObjectTypeInfo // subscription id -> objectType[]
+	Subscriptions map[uint32]*subscription
 }
 
 const path = "subscriptions.json"
 
-func newBroker() *net.Broker {
-	broker := net.NewBroker(map[string]string{"Access-Control-Allow-Origin": "*"})
-	broker.SetDisconnectCallback(func(clientId string, sessionId string) {
-		log.Printf("session %v of client %v was disconnected.", sessionId, clientId)
-	})
-	return broker
-}
+const restconfDataPath = "/restconf/data"
 
 func NewSubscriptionCenter() *SubscriptionCenter {
-	sc := &SubscriptionCenter{counter: 0, subscriptions: make(map[uint32][]openapi.ObjectTypeInfo), brokerMap: make(map[uint32]*net.Broker), connMap: make(map[uint32]map[string]*net.ClientConnection)}
+	sc := &SubscriptionCenter{counter: 0, subscriptions: make(map[uint32]*subscription), eventLog: newEventLog(defaultEventLogSize, ""), connMap: make(map[uint32]map[string]*connection)}
 	_, err := os.Stat(path)
 	if !os.IsNotExist(err) {
 		// file exists
@@ -51,7 +56,7 @@ func NewSubscriptionCenter() *SubscriptionCenter {
 		fileContent, _ = ioutil.ReadFile(path)
 		var dto subscriptionCenterDTO
 		err = json.Unmarshal(fileContent, &dto)
-		if err == nil {
+		if err == nil && dto.Subscriptions != nil {
 			sc.counter = dto.Counter
 			sc.subscriptions = dto.Subscriptions
 		}
@@ -60,9 +65,11 @@ func NewSubscriptionCenter() *SubscriptionCenter {
 }
 
 func (subscriptionCenter *SubscriptionCenter) Save() (err error) {
+	subscriptionCenter.saveM.Lock()
+	defer subscriptionCenter.saveM.Unlock()
 	dto := subscriptionCenterDTO{
-		Counter:       subscriptionCenter.counter,
-		Subscriptions: subscriptionCenter.subscriptions,
+		Counter:       atomic.LoadUint32(&subscriptionCenter.counter),
+		Subscriptions: subscriptionCenter.snapshot(),
 	}
 	data, err := json.Marshal(dto)
 	if err != nil {
@@ -72,127 +79,350 @@ func (subscriptionCenter *SubscriptionCenter) Save() (err error) {
 	return
 }
 
-func (subscriptionCenter *SubscriptionCenter) Subscribe(subscriptions []openapi.Subscription) (resultId uint32) {
-	if subscriptionCenter.subscriptions == nil {
-		subscriptionCenter.subscriptions = make(map[uint32][]openapi.ObjectTypeInfo)
+// snapshot returns a copy of the map of the subscriptions, to be iterated without the lock.
+func (subscriptionCenter *SubscriptionCenter) snapshot() map[uint32]*subscription {
+	subscriptionCenter.m.RLock()
+	defer subscriptionCenter.m.RUnlock()
+	subscriptions := make(map[uint32]*subscription, len(subscriptionCenter.subscriptions))
+	for id, subscription := range subscriptionCenter.subscriptions {
+		subscriptions[id] = subscription
+	}
+	return subscriptions
+}
+
+func (subscriptionCenter *SubscriptionCenter) lookup(id uint32) *subscription {
+	subscriptionCenter.m.RLock()
+	defer subscriptionCenter.m.RUnlock()
+	return subscriptionCenter.subscriptions[id]
+}
+
+// SetEventLog retains the given number of events for replay, saving them to the file at path when it is not empty.
+func (subscriptionCenter *SubscriptionCenter) SetEventLog(size int, path string) {
+	subscriptionCenter.eventLog = newEventLog(size, path)
+}
+
+// ReplayLog tells whether the events are retained to be replayed to the subscriptions, and when the log
+// retaining them was created.
+func (subscriptionCenter *SubscriptionCenter) ReplayLog() (supported bool, creationTime time.Time) {
+	return subscriptionCenter.eventLog.size > 0, subscriptionCenter.eventLog.creationTime()
+}
+
+// SetXMLNamespaces sets the namespaces of the modules, in which the notifications of the subscriptions
+// encoded in XML are written.
+func (subscriptionCenter *SubscriptionCenter) SetXMLNamespaces(namespaces yangxml.Namespaces) {
+	subscriptionCenter.xmlNamespaces = namespaces
+}
+
+// Subscribe establishes the subscription requested by the input on behalf of the owner, identifying the subscriber.
+// It fails when the encoding, the filter, the period or the replay of the subscription is invalid or unsupported.
+// The replay-start-time is revised to the time of the oldest retained event when it is earlier.
+func (subscriptionCenter *SubscriptionCenter) Subscribe(input openapi.EstablishSubscriptionInput, owner string) (openapi.EstablishSubscriptionOutput, *openapi.RestconfError) {
+	output := openapi.EstablishSubscriptionOutput{}
+	established := &subscription{
+		Owner: owner,
+		subscriptionFilter: subscriptionFilter{
+			XPathFilter:   input.Input.DatastoreXPathFilter,
+			SubtreeFilter: input.Input.DatastoreSubtreeFilter,
+		},
+	}
+	switch encoding := strings.TrimPrefix(input.Input.Encoding, "ietf-subscribed-notifications:"); encoding {
+	case "", openapi.EncodeJSON:
+		established.Encoding = openapi.EncodeJSON
+	case openapi.EncodeXML:
+		established.Encoding = openapi.EncodeXML
+	default:
+		restconfError := openapi.EncodingUnsupportedError()
+		return output, &restconfError
+	}
+	err := established.parseFilter()
+	if err != nil {
+		restconfError := openapi.FilterUnsupportedError(err.Error())
+		return output, &restconfError
+	}
+	if input.Input.Periodic != nil && input.Input.OnChange != nil {
+		restconfError := openapi.InvalidValueError("", "A subscription is either periodic or on-change")
+		return output, &restconfError
+	}
+	if periodic := input.Input.Periodic; periodic != nil {
+		if restconfError := validatePeriodic(periodic); restconfError != nil {
+			return output, restconfError
+		}
+		established.Period = periodic.Period
+		established.AnchorTime = periodic.AnchorTime
+	}
+	if onChange := input.Input.OnChange; onChange != nil {
+		// sync-on-start defaults to true, for the subscriptions asking for on-change options only
+		established.DampeningPeriod = onChange.DampeningPeriod
+		established.SyncOnStart = onChange.SyncOnStart == nil || *onChange.SyncOnStart
+		established.ExcludedChanges = onChange.ExcludedChange
+	}
+	if restconfError := validateStopTime(input.Input.StopTime); restconfError != nil {
+		return output, restconfError
+	}
+	established.StopTime = input.Input.StopTime
+	if restconfError := validateReplayStartTime(input.Input.ReplayStartTime, established.Period > 0); restconfError != nil {
+		return output, restconfError
+	}
+	if replayStartTime := input.Input.ReplayStartTime; replayStartTime != "" {
+		if subscriptionCenter.eventLog.size == 0 {
+			restconfError := openapi.ReplayUnsupportedError("No events are retained for replay")
+			return output, &restconfError
+		}
+		established.ReplayStartTime = replayStartTime
+		start, _ := time.Parse(time.RFC3339, replayStartTime)
+		if retainedSince := subscriptionCenter.eventLog.retainedSince(); start.Before(retainedSince) {
+			output.ReplayStartTimeRevision = retainedSince.Format(time.RFC3339Nano)
+			established.ReplayStartTime = output.ReplayStartTimeRevision
+		}
 	}
-	resultId = atomic.AddUint32(&subscriptionCenter.counter, 1)
 	objectTypeInfoSet := set.NewHashSet()
-	for _, subscription := range subscriptions {
+	for _, subscription := range input.Input.Subscription.Subscription {
 		objectTypeInfoSet.Add(subscription.ObjectTypeInfo)
 	}
-	subscriptionCenter.subscriptions[resultId] = make([]openapi.ObjectTypeInfo, objectTypeInfoSet.Len())
+	established.ObjectTypes = make([]openapi.ObjectTypeInfo, objectTypeInfoSet.Len())
 	for i, objectTypeInfo := range objectTypeInfoSet.Elements() {
-		subscriptionCenter.subscriptions[resultId][i] = objectTypeInfo.(openapi.ObjectTypeInfo)
+		established.ObjectTypes[i] = objectTypeInfo.(openapi.ObjectTypeInfo)
 	}
+	resultId := atomic.AddUint32(&subscriptionCenter.counter, 1)
+	subscriptionCenter.m.Lock()
+	subscriptionCenter.subscriptions[resultId] = established
+	subscriptionCenter.m.Unlock()
+	established.m.Lock()
+	if established.Period > 0 {
+		subscriptionCenter.startTimer(resultId, established)
+	}
+	// armed once the subscription is registered, as a stop time in the past completes it at once
+	subscriptionCenter.scheduleStopTime(resultId, established)
+	established.m.Unlock()
 	_ = subscriptionCenter.Save()
-	subscriptionCenter.brokerMap[resultId] = newBroker()
-	return
+	output.ID = resultId
+	return output, nil
 }
 
-func (subscriptionCenter *SubscriptionCenter) Get(id uint32) []openapi.ObjectTypeInfo {
-	return subscriptionCenter.subscriptions[id]
+func (subscriptionCenter *SubscriptionCenter) Exists(id uint32) bool {
+	return subscriptionCenter.lookup(id) != nil
 }
 
-func (subscriptionCenter *SubscriptionCenter) Delete(id uint32) bool {
-	if subscriptionCenter.subscriptions[id] == nil {
+// Unsubscribe deletes a subscription of the owner, as delete-subscription does. The subscriptions of the other
+// clients are deleted by Kill only.
+func (subscriptionCenter *SubscriptionCenter) Unsubscribe(id uint32, owner string) bool {
+	subscription := subscriptionCenter.lookup(id)
+	if subscription == nil || subscription.Owner != owner {
 		return false
 	}
+	return subscriptionCenter.Delete(id)
+}
+
+// Encoding returns the encoding of the notification messages of the subscription,
+// or an empty string when there is no such subscription.
+func (subscriptionCenter *SubscriptionCenter) Encoding(id uint32) string {
+	subscription := subscriptionCenter.lookup(id)
+	if subscription == nil {
+		return ""
+	}
+	return subscription.encoding()
+}
+
+// Delete removes the subscription, ending the streams of its clients once their queued events are written.
+func (subscriptionCenter *SubscriptionCenter) Delete(id uint32) bool {
+	subscriptionCenter.m.Lock()
+	subscription := subscriptionCenter.subscriptions[id]
+	connections := subscriptionCenter.connMap[id]
 	delete(subscriptionCenter.subscriptions, id)
-	if subscriptionCenter.connMap[id] != nil {
-		delete(subscriptionCenter.connMap, id)
+	delete(subscriptionCenter.connMap, id)
+	subscriptionCenter.m.Unlock()
+	if subscription == nil {
+		return false
 	}
-	if subscriptionCenter.brokerMap[id] != nil {
-		err := subscriptionCenter.brokerMap[id].Close()
-		if err != nil {
-			println(err)
-		}
+	subscription.m.Lock()
+	subscriptionCenter.stopTimer(subscription)
+	subscription.stopTimers()
+	subscription.m.Unlock()
+	for _, conn := range connections {
+		conn.end()
 	}
-	delete(subscriptionCenter.brokerMap, id)
 	err := subscriptionCenter.Save()
 	if err != nil {
-		println(err)
+		log.Printf("saving the subscriptions failed: %v", err)
 	}
 	return true
 }
 
+// Connect streams the notifications of the subscription to a client, as server-sent events or over a WebSocket
+// when the request is upgraded, both getting the same events in the encoding of the subscription. A client reconnecting with the ID of the last
+// event it received in the Last-Event-ID header resumes the stream, instead of starting it again.
 func (subscriptionCenter *SubscriptionCenter) Connect(id uint32, interval uint64, w http.ResponseWriter, r *http.Request) (err error) {
 	clientId := uuid.New().String()
-	if subscriptionCenter.brokerMap[id] == nil {
-		subscriptionCenter.brokerMap[id] = newBroker()
+	conn := newConnection(id)
+	if subscriptionCenter.Encoding(id) == openapi.EncodeXML {
+		conn.xmlNamespaces = subscriptionCenter.xmlNamespaces
+		conn.xml = true
 	}
-	println("Connecting with new client to subscription ", id)
-	conn, err := subscriptionCenter.brokerMap[id].ConnectWithHeartBeatInterval(clientId, w, r, time.Duration(interval)*time.Second)
-	if err != nil {
+	if lastEventID, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		subscriptionCenter.resume(id, clientId, conn, lastEventID)
+	} else {
+		subscriptionCenter.register(id, clientId, conn)
+		go subscriptionCenter.start(id, conn)
+	}
+	if isWebSocket(r) {
+		err = conn.serveWebSocket(w, r, time.Duration(interval)*time.Second)
+	} else {
+		err = conn.serveSSE(w, r, time.Duration(interval)*time.Second)
+	}
+	conn.close()
+	subscriptionCenter.unregister(id, clientId)
+	log.Printf("client %v of subscription %v was disconnected.", clientId, id)
+	return err
+}
+
+func (subscriptionCenter *SubscriptionCenter) register(id uint32, clientId string, conn *connection) {
+	subscriptionCenter.m.Lock()
+	defer subscriptionCenter.m.Unlock()
+	if subscriptionCenter.subscriptions[id] == nil {
+		// the subscription was deleted in the meantime
+		conn.end()
 		return
 	}
 	if subscriptionCenter.connMap[id] == nil {
-		subscriptionCenter.connMap[id] = map[string]*net.ClientConnection{}
+		subscriptionCenter.connMap[id] = map[string]*connection{}
 	}
 	subscriptionCenter.connMap[id][clientId] = conn
-	println("Connected with new client to subscription ", id, "with session id ", conn.SessionId())
-	<-conn.Done()
+}
+
+func (subscriptionCenter *SubscriptionCenter) unregister(id uint32, clientId string) {
+	subscriptionCenter.m.Lock()
+	defer subscriptionCenter.m.Unlock()
 	delete(subscriptionCenter.connMap[id], clientId)
-	return nil
 }
 
-func (subscriptionCenter *SubscriptionCenter) SendAll(objectType openapi.ObjectTypeInfo, operation openapi.Operation, value interface{}, ids ...string) error {
-	target, err := objectTypeToTarget(objectType, ids...)
-	if err != nil {
+// connections returns the clients connected to the stream of the subscription.
+func (subscriptionCenter *SubscriptionCenter) connections(id uint32) []*connection {
+	subscriptionCenter.m.RLock()
+	defer subscriptionCenter.m.RUnlock()
+	connections := make([]*connection, 0, len(subscriptionCenter.connMap[id]))
+	for _, conn := range subscriptionCenter.connMap[id] {
+		connections = append(connections, conn)
+	}
+	return connections
+}
+
+// Publish notifies the subscriptions of the changes between two versions of the running datastore.
+// Each subscription receives a push-change-update holding the edits it selects.
+// The subscriptions of the originator of the changes are left out, unless it is empty.
+// The changes are retained in the event log, to be replayed to the subscriptions established later.
+// Periodic subscriptions only send the content of the datastore, and suspended subscriptions send nothing.
+func (subscriptionCenter *SubscriptionCenter) Publish(previous *ajson.Node, after *ajson.Node, originator string) error {
+	changes, err := database.Diff(previous, after, subscriptionCenter.schema)
+	if err != nil || len(changes) == 0 {
 		return err
 	}
-	for subscriptionID, objectTypes := range subscriptionCenter.subscriptions {
-		for _, t := range objectTypes {
-			if t == objectType {
-				notification := openapi.NewRestconfNotification(subscriptionID, operation, target, value)
-				subscriptionCenter.Send(notification)
-				break
-			}
+	subscriptionCenter.eventLog.append(changes)
+	for subscriptionID, subscription := range subscriptionCenter.snapshot() {
+		if subscription.periodic() || (originator != "" && subscription.Owner == originator) {
+			continue
+		}
+		err = subscriptionCenter.notifyChanges(subscriptionID, subscription, changes, previous, after)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func objectTypeToTarget(objectType openapi.ObjectTypeInfo, unescapedIds ...string) (string, error) {
-	ids := make([]string, len(unescapedIds))
-	for i := range unescapedIds {
-		ids[i] = url.QueryEscape(unescapedIds[i])
-	}
-	switch objectType {
-	case openapi.ObjectTypeInfoNode:
-		return "/restconf/data/ietf-network:networks/network=" + ids[0] + "/node=" + ids[1], nil
-	case openapi.ObjectTypeInfoTP:
-		return "/restconf/data/ietf-network:networks/network=" + ids[0] + "/node=" + ids[1] + "/ietf-network-topology:termination-point=" + ids[2], nil
-	case openapi.ObjectTypeInfoTTP:
-		return "/restconf/data/ietf-network:networks/network=" + ids[0] + "/node=" + ids[1] + "/ietf-te-topology:te/tunnel-termination-point=" + ids[2], nil
-	case openapi.ObjectTypeInfoLink:
-		return "/restconf/data/ietf-network:networks/network=" + ids[0] + "/ietf-network-topology:link=" + ids[1], nil
-	default:
-		return "", errors.New(string("Object type " + objectType + " not supported"))
+func yangPatchEdits(changes []database.Change) ([]openapi.YangPatchEdit, error) {
+	edits := make([]openapi.YangPatchEdit, len(changes))
+	for i, change := range changes {
+		edits[i] = openapi.YangPatchEdit{Operation: openapi.Operation(change.Operation), Target: restconfDataPath + change.Target}
+		if change.Value != nil {
+			value, err := change.Value.Unpack()
+			if err != nil {
+				return nil, err
+			}
+			edits[i].Value = value
+		}
+	}
+	return edits, nil
+}
+
+// objectTypeLists maps the object types of the subscriptions to the local names of the lists holding their entries.
+var objectTypeLists = map[string]openapi.ObjectTypeInfo{
+	"node":                     openapi.ObjectTypeInfoNode,
+	"link":                     openapi.ObjectTypeInfoLink,
+	"termination-point":        openapi.ObjectTypeInfoTP,
+	"tunnel-termination-point": openapi.ObjectTypeInfoTTP,
+	"tunnel":                   openapi.ObjectTypeInfoTunnel,
+	"client-svc-instances":     openapi.ObjectTypeInfoClientService,
+	"etht-svc-instances":       openapi.ObjectTypeInfoEthTranService,
+	"service-pm":               openapi.ObjectTypeInfoServicePm,
+}
+
+// targetObjectType returns the object type of the list entry a RESTCONF target points to.
+func targetObjectType(target string) (openapi.ObjectTypeInfo, bool) {
+	segment := target[strings.LastIndex(target, "/")+1:]
+	name, _, isEntry := strings.Cut(segment, "=")
+	if !isEntry {
+		return "", false
+	}
+	objectType, ok := objectTypeLists[name[strings.Index(name, ":")+1:]]
+	return objectType, ok
+}
+
+func containsObjectType(objectTypes []openapi.ObjectTypeInfo, objectType openapi.ObjectTypeInfo) bool {
+	for _, t := range objectTypes {
+		if t == objectType {
+			return true
+		}
 	}
+	return false
 }
 
+// Send queues the notification for the clients of its subscription, numbering it among the events of the subscription.
+// It never waits for the clients.
 func (subscriptionCenter *SubscriptionCenter) Send(notification openapi.RestconfNotification) {
-	for _, conn := range subscriptionCenter.connMap[notification.Notification.PushChangeUpdate.SubscriptionID] {
-		conn.Send(&RestconfEvent{
-			Data: notification,
-		})
+	id := notification.SubscriptionID()
+	event := &RestconfEvent{Data: notification}
+	if subscription := subscriptionCenter.lookup(id); subscription != nil {
+		subscription.events.m.Lock()
+		defer subscription.events.m.Unlock()
+		subscription.events.add(event)
+	}
+	for _, conn := range subscriptionCenter.connections(id) {
+		conn.send(event)
 	}
 }
 
+// RestconfEvent is a server-sent event holding a notification. Only the events sent to all the clients of
+// a subscription have an ID.
 type RestconfEvent struct {
+	Id   string
 	Data interface{}
 }
 
 func (e RestconfEvent) Prepare() []byte {
-	var data bytes.Buffer
-
 	marshal, err := json.Marshal(e.Data)
 	if err != nil {
 		logrus.Errorf("error marshaling JSONEvent: %v", err)
 		return []byte{}
 	}
+	return e.prepare(marshal)
+}
+
+// PrepareXML formats the event as Prepare does, its notification being encoded in XML.
+func (e RestconfEvent) PrepareXML(namespaces yangxml.Namespaces) []byte {
+	marshal, err := e.marshalXML(namespaces)
+	if err != nil {
+		logrus.Errorf("error marshaling XMLEvent: %v", err)
+		return []byte{}
+	}
+	return e.prepare(marshal)
+}
+
+func (e RestconfEvent) prepare(marshal []byte) []byte {
+	var data bytes.Buffer
 
+	if e.Id != "" {
+		data.WriteString(fmt.Sprintf("id: %s\n", e.Id))
+	}
 	data.WriteString(fmt.Sprintf("data: %s\n", string(marshal)))
 	data.WriteString("\n")
 
@@ -200,7 +430,7 @@ func (e RestconfEvent) Prepare() []byte {
 }
 
 func (e RestconfEvent) GetId() string {
-	return ""
+	return e.Id
 }
 
 func (e RestconfEvent) GetEvent() string {
@@ -215,3 +445,21 @@ func (e RestconfEvent) GetData() string {
 	}
 	return string(marshal)
 }
+
+// GetXMLData returns the notification of the event encoded in XML.
+func (e RestconfEvent) GetXMLData(namespaces yangxml.Namespaces) string {
+	marshal, err := e.marshalXML(namespaces)
+	if err != nil {
+		logrus.Errorf("error marshaling XMLEvent: %v", err)
+		return ""
+	}
+	return string(marshal)
+}
+
+func (e RestconfEvent) marshalXML(namespaces yangxml.Namespaces) ([]byte, error) {
+	notification, isNotification := e.Data.(openapi.RestconfNotification)
+	if !isNotification {
+		return nil, fmt.Errorf("%T is not a notification", e.Data)
+	}
+	return notification.MarshalYangXML(namespaces)
+}
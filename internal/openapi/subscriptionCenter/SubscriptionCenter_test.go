@@ -0,0 +1,261 @@
+package subscriptionCenter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// recordingWriter is a streaming response writer keeping what is written.
+type recordingWriter struct {
+	header http.Header
+	m      sync.Mutex
+	body   bytes.Buffer
+}
+
+func (writer *recordingWriter) Header() http.Header        { return writer.header }
+func (writer *recordingWriter) WriteHeader(statusCode int) {}
+func (writer *recordingWriter) Flush()                     {}
+
+func (writer *recordingWriter) Write(data []byte) (int, error) {
+	writer.m.Lock()
+	defer writer.m.Unlock()
+	return writer.body.Write(data)
+}
+
+func (writer *recordingWriter) count(text string) int {
+	writer.m.Lock()
+	defer writer.m.Unlock()
+	return strings.Count(writer.body.String(), text)
+}
+
+// blockingWriter is a streaming response writer of a client which stops reading.
+type blockingWriter struct {
+	header  http.Header
+	release chan struct{}
+}
+
+func (writer *blockingWriter) Header() http.Header        { return writer.header }
+func (writer *blockingWriter) WriteHeader(statusCode int) {}
+func (writer *blockingWriter) Flush()                     {}
+
+func (writer *blockingWriter) Write(data []byte) (int, error) {
+	<-writer.release
+	return len(data), nil
+}
+
+func inTempDir(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(dir) })
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubscriptionCenter_Publish_HundredsOfSubscribersWithSlowOnes_PublishersNotBlocked(t *testing.T) {
+	inTempDir(t)
+	const fastSubscribers, slowSubscribers, publishers, publications = 250, 50, 8, 40
+	subscriptionCenter := NewSubscriptionCenter()
+	var contentM sync.Mutex
+	content := ajson.Must(ajson.Unmarshal([]byte(`{"example:counter":0}`)))
+	subscriptionCenter.SetDatastore(func() (*ajson.Node, error) {
+		contentM.Lock()
+		defer contentM.Unlock()
+		return content, nil
+	}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	var clients sync.WaitGroup
+	connect := func(writer http.ResponseWriter) uint32 {
+		output, restconfError := subscriptionCenter.Subscribe(openapi.EstablishSubscriptionInput{}, "controller")
+		if restconfError != nil {
+			t.Fatal(restconfError.ErrorMessage)
+		}
+		request := httptest.NewRequest(http.MethodGet, "/restconf/streams/yang-push-json", nil).WithContext(ctx)
+		clients.Add(1)
+		go func() {
+			defer clients.Done()
+			_ = subscriptionCenter.Connect(output.ID, 15, writer, request)
+		}()
+		return output.ID
+	}
+	fastWriters := make([]*recordingWriter, fastSubscribers)
+	for i := range fastWriters {
+		fastWriters[i] = &recordingWriter{header: http.Header{}}
+		connect(fastWriters[i])
+	}
+	slowIDs := make([]uint32, slowSubscribers)
+	for i := range slowIDs {
+		slowIDs[i] = connect(&blockingWriter{header: http.Header{}, release: release})
+	}
+	defer func() {
+		cancel()
+		close(release)
+		clients.Wait()
+	}()
+	waitFor(t, func() bool {
+		for id := uint32(1); id <= fastSubscribers+slowSubscribers; id++ {
+			if len(subscriptionCenter.connections(id)) == 0 {
+				return false
+			}
+		}
+		return true
+	})
+
+	published := make(chan struct{})
+	go func() {
+		var wait sync.WaitGroup
+		for p := 0; p < publishers; p++ {
+			wait.Add(1)
+			go func(p int) {
+				defer wait.Done()
+				for i := 0; i < publications; i++ {
+					previous := ajson.Must(ajson.Unmarshal([]byte(fmt.Sprintf(`{"example:counter":%d}`, p*publications+i))))
+					after := ajson.Must(ajson.Unmarshal([]byte(fmt.Sprintf(`{"example:counter":%d}`, p*publications+i+1))))
+					assert.NoError(t, subscriptionCenter.Publish(previous, after, ""))
+				}
+			}(p)
+		}
+		// the subscriptions change while the changes are published
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for id := uint32(1); id <= 20; id++ {
+				subscriptionCenter.Suspend(id, openapi.ReasonInsufficientResources)
+				subscriptionCenter.Resume(id)
+				input := openapi.ModifySubscriptionInput{}
+				input.Input.ID = id
+				input.Input.DatastoreXPathFilter = "/example:counter"
+				assert.Nil(t, subscriptionCenter.Modify(input, "controller"))
+				output, _ := subscriptionCenter.Subscribe(openapi.EstablishSubscriptionInput{}, "controller")
+				subscriptionCenter.Kill(output.ID)
+			}
+		}()
+		wait.Wait()
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(30 * time.Second):
+		t.Fatal("the publishers were blocked by the subscribers")
+	}
+
+	waitFor(t, func() bool {
+		for _, writer := range fastWriters {
+			if writer.count("ietf-yang-push:push-change-update") == 0 {
+				return false
+			}
+		}
+		return true
+	})
+	for _, id := range slowIDs {
+		for _, conn := range subscriptionCenter.connections(id) {
+			assert.Len(t, conn.queue, connectionQueueSize)
+			conn.m.Lock()
+			assert.True(t, conn.overflowed)
+			conn.m.Unlock()
+		}
+	}
+}
+
+func TestSubscriptionCenter_Connect_WebSocketUpgrade_NotificationsReceivedAsJSON(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	output, _ := subscriptionCenter.Subscribe(openapi.EstablishSubscriptionInput{}, "controller")
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_ = subscriptionCenter.Connect(output.ID, 15, writer, request)
+	}))
+	defer server.Close()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	var started string
+	err = websocket.Message.Receive(ws, &started)
+
+	subscriptionCenter.Send(openapi.NewRestconfNotification(output.ID))
+	var update string
+	updateErr := websocket.Message.Receive(ws, &update)
+
+	assert.NoError(t, err)
+	assert.Contains(t, started, `"ietf-subscribed-notifications:subscription-started":{"id":1`)
+	assert.NoError(t, updateErr)
+	assert.True(t, json.Valid([]byte(update)))
+	assert.Contains(t, update, `"ietf-yang-push:push-change-update":{"subscription-id":1`)
+}
+
+func TestSubscriptionCenter_Subscribe_UnknownEncoding_EncodingUnsupportedError(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	input := openapi.EstablishSubscriptionInput{}
+	input.Input.Encoding = "ietf-subscribed-notifications:encode-cbor"
+
+	_, restconfError := subscriptionCenter.Subscribe(input, "controller")
+
+	if assert.NotNil(t, restconfError) {
+		assert.Equal(t, "ietf-subscribed-notifications:encoding-unsupported", restconfError.ErrorAppTag)
+	}
+}
+
+func TestSubscriptionCenter_Connect_XMLEncodedSubscription_NotificationsInNetconfEnvelope(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	subscriptionCenter.SetXMLNamespaces(yangxml.Namespaces{"example": "http://example.com/ns/example"})
+	input := openapi.EstablishSubscriptionInput{}
+	input.Input.Encoding = "ietf-subscribed-notifications:encode-xml"
+	output, _ := subscriptionCenter.Subscribe(input, "controller")
+	ctx, cancel := context.WithCancel(context.Background())
+	writer := &recordingWriter{header: http.Header{}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		request := httptest.NewRequest(http.MethodGet, "/restconf/streams/yang-push-xml", nil).WithContext(ctx)
+		_ = subscriptionCenter.Connect(output.ID, 15, writer, request)
+	}()
+	waitFor(t, func() bool { return len(subscriptionCenter.connections(output.ID)) > 0 })
+
+	subscriptionCenter.Send(openapi.NewRestconfNotification(output.ID, openapi.YangPatchEdit{
+		Operation: "replace",
+		Target:    "/example:counter",
+		Value:     map[string]interface{}{"example:counter": 1},
+	}))
+	waitFor(t, func() bool { return writer.count("push-change-update") > 0 })
+	cancel()
+	<-done
+
+	body := writer.body.String()
+	assert.Equal(t, 2, strings.Count(body, `data: <notification xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><eventTime>`))
+	assert.Contains(t, body, `<subscription-started xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"><id>1</id>`)
+	assert.Contains(t, body, `<push-change-update xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push">`)
+	assert.Contains(t, body, `<yang-patch xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-patch">`)
+	assert.Contains(t, body, `<value><counter xmlns="http://example.com/ns/example">1</counter></value>`)
+}
@@ -0,0 +1,194 @@
+package subscriptionCenter
+
+import (
+	net "github.com/exgphe/go-sse"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/muonsoft/openapi-mock/internal/openapi/yangxml"
+	"github.com/pkg/errors"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// connectionQueueSize is the number of events waiting to be written to a client, beyond which the events are dropped.
+// It holds the events replayed to a resuming client.
+const connectionQueueSize = 2 * eventBufferSize
+
+// connection is a client connected to the stream of a subscription. The events are queued, to be written by
+// the goroutine serving the client, so that a slow client never holds up the publishers. The events which do not
+// fit in the queue are dropped, the client being told by a push-change-update flagging updates-not-sent.
+type connection struct {
+	subscriptionID uint32
+	queue          chan *RestconfEvent
+	m              sync.Mutex
+	overflowed     bool          // events were dropped since the last queued event
+	finish         chan struct{} // ends the stream once the queued events are written
+	finishOnce     sync.Once
+	closed         chan struct{} // closed when the client is not served anymore
+	xml            bool          // the notifications are encoded in XML, in the namespaces below
+	xmlNamespaces  yangxml.Namespaces
+}
+
+func newConnection(subscriptionID uint32) *connection {
+	return &connection{
+		subscriptionID: subscriptionID,
+		queue:          make(chan *RestconfEvent, connectionQueueSize),
+		finish:         make(chan struct{}),
+		closed:         make(chan struct{}),
+	}
+}
+
+// send queues the event without waiting, dropping it when the queue is full.
+func (connection *connection) send(event *RestconfEvent) {
+	connection.m.Lock()
+	defer connection.m.Unlock()
+	if connection.overflowed {
+		select {
+		case connection.queue <- &RestconfEvent{Data: openapi.NewUpdatesNotSentNotification(connection.subscriptionID)}:
+			connection.overflowed = false
+		default:
+			return
+		}
+	}
+	select {
+	case connection.queue <- event:
+	default:
+		connection.overflowed = true
+	}
+}
+
+// sendWait queues the event, waiting for the queue to make room for it unless the client goes away.
+func (connection *connection) sendWait(event *RestconfEvent) {
+	select {
+	case connection.queue <- event:
+	case <-connection.closed:
+	}
+}
+
+// close tells the senders waiting for the queue that the client is not served anymore.
+func (connection *connection) close() {
+	close(connection.closed)
+}
+
+// end ends the stream of the client, after the events already queued.
+func (connection *connection) end() {
+	connection.finishOnce.Do(func() {
+		close(connection.finish)
+	})
+}
+
+// serveSSE writes the queued events to the client as server-sent events, with a heartbeat every interval
+// unless it is zero, until the client goes away or the stream ends.
+func (connection *connection) serveSSE(w http.ResponseWriter, r *http.Request, interval time.Duration) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+		return errors.New("the response writer does not support flushing")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return connection.serve(r.Context().Done(), interval, func(event *RestconfEvent) error {
+		_, err := w.Write(connection.prepare(event))
+		flusher.Flush()
+		return err
+	}, func() error {
+		_, err := w.Write(net.HeartbeatEvent{}.Prepare())
+		flusher.Flush()
+		return err
+	})
+}
+
+// prepare formats the event as a server-sent event, in the encoding of the notifications.
+func (connection *connection) prepare(event *RestconfEvent) []byte {
+	if connection.xml {
+		return event.PrepareXML(connection.xmlNamespaces)
+	}
+	return event.Prepare()
+}
+
+// data returns the notification of the event, in the encoding of the notifications.
+func (connection *connection) data(event *RestconfEvent) string {
+	if connection.xml {
+		return event.GetXMLData(connection.xmlNamespaces)
+	}
+	return event.GetData()
+}
+
+// isWebSocket tells whether the request asks for the stream to be upgraded to a WebSocket.
+func isWebSocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// serveWebSocket upgrades the request to a WebSocket, then writes the queued events to the client as text messages
+// holding the notifications, with a ping every interval, until the client goes away or the stream ends.
+func (connection *connection) serveWebSocket(w http.ResponseWriter, r *http.Request, interval time.Duration) (err error) {
+	server := websocket.Server{
+		// any origin is accepted, like the Access-Control-Allow-Origin of the server-sent events
+		Handshake: func(config *websocket.Config, request *http.Request) error {
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			gone := make(chan struct{})
+			go func() {
+				// the messages of the client are discarded, while its pongs and close frames are handled
+				var message []byte
+				for websocket.Message.Receive(ws, &message) == nil {
+				}
+				close(gone)
+			}()
+			err = connection.serve(gone, interval, func(event *RestconfEvent) error {
+				return websocket.Message.Send(ws, connection.data(event))
+			}, func() error {
+				ws.PayloadType = websocket.PingFrame
+				defer func() { ws.PayloadType = websocket.TextFrame }()
+				_, err := ws.Write(nil)
+				return err
+			})
+		},
+	}
+	server.ServeHTTP(w, r)
+	return err
+}
+
+// serve writes the queued events until the client is gone or the stream ends, and a heartbeat every interval
+// unless it is zero.
+func (connection *connection) serve(gone <-chan struct{}, interval time.Duration, write func(event *RestconfEvent) error, heartbeat func() error) error {
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	for {
+		var err error
+		select {
+		case <-gone:
+			return nil
+		case <-ticks:
+			err = heartbeat()
+		case event := <-connection.queue:
+			err = write(event)
+		case <-connection.finish:
+			for {
+				select {
+				case event := <-connection.queue:
+					err = write(event)
+					if err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
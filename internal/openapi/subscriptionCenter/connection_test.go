@@ -0,0 +1,61 @@
+package subscriptionCenter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection_Send_QueueFull_EventDroppedAndUpdatesNotSentQueuedNext(t *testing.T) {
+	conn := newConnection(1)
+	for i := 0; i < connectionQueueSize; i++ {
+		conn.send(&RestconfEvent{Id: "queued"})
+	}
+
+	conn.send(&RestconfEvent{Id: "dropped"})
+	<-conn.queue
+	<-conn.queue
+	conn.send(&RestconfEvent{Id: "next"})
+
+	assert.Len(t, conn.queue, connectionQueueSize)
+	var last, beforeLast *RestconfEvent
+	for len(conn.queue) > 0 {
+		beforeLast, last = last, <-conn.queue
+	}
+	assert.Equal(t, "next", last.Id)
+	if assert.IsType(t, openapi.RestconfNotification{}, beforeLast.Data) {
+		update := beforeLast.Data.(openapi.RestconfNotification).Notification.PushChangeUpdate
+		if assert.NotNil(t, update) {
+			assert.Equal(t, uint32(1), update.SubscriptionID)
+			assert.NotEmpty(t, update.UpdatesNotSent)
+		}
+	}
+}
+
+func TestConnection_SendWait_ClientGone_Returns(t *testing.T) {
+	conn := newConnection(1)
+	for i := 0; i < connectionQueueSize; i++ {
+		conn.send(&RestconfEvent{})
+	}
+	close(conn.closed)
+
+	conn.sendWait(&RestconfEvent{})
+
+	assert.Len(t, conn.queue, connectionQueueSize)
+}
+
+func TestConnection_ServeSSE_ZeroInterval_QueuedEventsWrittenWithoutHeartbeat(t *testing.T) {
+	conn := newConnection(1)
+	conn.send(&RestconfEvent{Id: "1", Data: openapi.NewRestconfNotification(1)})
+	conn.end()
+	writer := &recordingWriter{header: http.Header{}}
+
+	err := conn.serveSSE(writer, httptest.NewRequest(http.MethodGet, "/", nil), 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, writer.count("id: 1\n"))
+	assert.Equal(t, 0, writer.count(": This is a heartbeat message."))
+}
@@ -0,0 +1,114 @@
+package subscriptionCenter
+
+import (
+	"encoding/json"
+	"github.com/muonsoft/openapi-mock/database"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultEventLogSize is the number of events retained for replay, unless configured otherwise.
+const defaultEventLogSize = 1000
+
+// eventRecord is an event of the stream, made of the changes written to the running datastore at once.
+type eventRecord struct {
+	EventTime time.Time
+	Changes   []database.Change
+}
+
+// eventLog retains the latest events of the stream, to be replayed to the subscriptions asking for them.
+// It is saved to the file at path, if any, and read from it on start.
+type eventLog struct {
+	m    sync.Mutex
+	size int
+	path string
+	// Created is the time the log was created, before the server restarted when it is saved
+	Created time.Time
+	// Start is the time since which the events are retained, the time of the oldest retained event
+	// once older events were dropped
+	Start   time.Time
+	Records []eventRecord
+}
+
+func newEventLog(size int, path string) *eventLog {
+	now := time.Now()
+	eventLog := &eventLog{size: size, path: path, Created: now, Start: now}
+	if path == "" {
+		return eventLog
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return eventLog
+	}
+	fileContent, err := ioutil.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(fileContent, eventLog)
+	}
+	if eventLog.Created.IsZero() || eventLog.Created.After(eventLog.Start) {
+		// saved before the creation time was
+		eventLog.Created = eventLog.Start
+	}
+	eventLog.trim()
+	return eventLog
+}
+
+// append records the changes as a new event.
+func (eventLog *eventLog) append(changes []database.Change) {
+	eventLog.m.Lock()
+	defer eventLog.m.Unlock()
+	eventLog.Records = append(eventLog.Records, eventRecord{EventTime: time.Now(), Changes: changes})
+	eventLog.trim()
+	if eventLog.path != "" {
+		_ = eventLog.save()
+	}
+}
+
+// trim drops the oldest events beyond the size of the log.
+func (eventLog *eventLog) trim() {
+	if len(eventLog.Records) <= eventLog.size {
+		return
+	}
+	eventLog.Records = append([]eventRecord(nil), eventLog.Records[len(eventLog.Records)-eventLog.size:]...)
+	if len(eventLog.Records) > 0 {
+		eventLog.Start = eventLog.Records[0].EventTime
+	} else {
+		eventLog.Start = time.Now()
+	}
+}
+
+func (eventLog *eventLog) save() error {
+	data, err := json.Marshal(eventLog)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(eventLog.path, data, fs.ModePerm)
+}
+
+// since returns the events which occurred from the start time.
+func (eventLog *eventLog) since(start time.Time) []eventRecord {
+	eventLog.m.Lock()
+	defer eventLog.m.Unlock()
+	var records []eventRecord
+	for _, record := range eventLog.Records {
+		if !record.EventTime.Before(start) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// retainedSince returns the time from which the events are retained.
+func (eventLog *eventLog) retainedSince() time.Time {
+	eventLog.m.Lock()
+	defer eventLog.m.Unlock()
+	return eventLog.Start
+}
+
+// creationTime returns the time the log was created.
+func (eventLog *eventLog) creationTime() time.Time {
+	eventLog.m.Lock()
+	defer eventLog.m.Unlock()
+	return eventLog.Created
+}
@@ -0,0 +1,194 @@
+package subscriptionCenter
+
+import (
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"log"
+	"time"
+)
+
+// start tells a client connecting to the subscription that it started, and replays the events since the
+// replay-start-time of the subscription, if any. It then sends the content of the datastore when the subscription
+// synchronizes on start.
+// The events are queued after the events already queued, waiting for the client to read them.
+func (subscriptionCenter *SubscriptionCenter) start(id uint32, conn *connection) {
+	subscription := subscriptionCenter.lookup(id)
+	if subscription == nil {
+		return
+	}
+	conn.sendWait(&RestconfEvent{Data: openapi.NewSubscriptionStateNotification(openapi.SubscriptionStarted, subscription.state(id))})
+	if subscription.ReplayStartTime != "" {
+		err := subscriptionCenter.replay(id, subscription, conn)
+		if err != nil {
+			log.Printf("replay of subscription %v failed: %v", id, err)
+		}
+		conn.sendWait(&RestconfEvent{Data: openapi.NewSubscriptionStateNotification(openapi.ReplayCompleted, openapi.SubscriptionState{ID: id})})
+	}
+	if subscription.periodic() || !subscription.SyncOnStart {
+		return
+	}
+	notification, err := subscriptionCenter.pushUpdateNotification(id, subscription)
+	if err != nil {
+		log.Printf("sync-on-start of subscription %v failed: %v", id, err)
+		return
+	}
+	if notification != nil {
+		conn.sendWait(&RestconfEvent{Data: *notification})
+	}
+}
+
+// replay sends the push-change-update notifications of the retained events the subscription selects,
+// which occurred from its replay-start-time until its stop-time, keeping the times of the events.
+func (subscriptionCenter *SubscriptionCenter) replay(id uint32, subscription *subscription, conn *connection) error {
+	start, err := time.Parse(time.RFC3339, subscription.ReplayStartTime)
+	if err != nil {
+		return err
+	}
+	subscription.m.Lock()
+	stopTime, stopErr := time.Parse(time.RFC3339, subscription.StopTime)
+	subscription.m.Unlock()
+	for _, record := range subscriptionCenter.eventLog.since(start) {
+		if stopErr == nil && record.EventTime.After(stopTime) {
+			break
+		}
+		selected, err := subscription.replayedChanges(record.Changes, subscriptionCenter.schema)
+		if err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			continue
+		}
+		edits, err := yangPatchEdits(selected)
+		if err != nil {
+			return err
+		}
+		notification := openapi.NewRestconfNotification(id, edits...)
+		notification.Notification.EventTime = openapi.FormatEventTime(record.EventTime)
+		conn.sendWait(&RestconfEvent{Data: notification})
+	}
+	return nil
+}
+
+// Modify changes the filter, the period, the dampening period or the stop time of a subscription of the owner.
+func (subscriptionCenter *SubscriptionCenter) Modify(input openapi.ModifySubscriptionInput, owner string) *openapi.RestconfError {
+	id := input.Input.ID
+	subscription := subscriptionCenter.lookup(id)
+	if subscription == nil || subscription.Owner != owner {
+		restconfError := openapi.NoSuchSubscriptionError()
+		return &restconfError
+	}
+	modified := &subscriptionFilter{XPathFilter: input.Input.DatastoreXPathFilter, SubtreeFilter: input.Input.DatastoreSubtreeFilter}
+	filtered := modified.XPathFilter != "" || len(modified.SubtreeFilter) > 0
+	if filtered {
+		if err := modified.parseFilter(); err != nil {
+			restconfError := openapi.FilterUnsupportedError(err.Error())
+			return &restconfError
+		}
+	}
+	if input.Input.Periodic != nil {
+		if restconfError := validatePeriodic(input.Input.Periodic); restconfError != nil {
+			return restconfError
+		}
+	}
+	if restconfError := validateStopTime(input.Input.StopTime); restconfError != nil {
+		return restconfError
+	}
+
+	subscription.m.Lock()
+	if (input.Input.Periodic != nil && subscription.Period == 0) || (input.Input.OnChange != nil && subscription.Period > 0) {
+		subscription.m.Unlock()
+		restconfError := openapi.InvalidValueError("", "A subscription cannot change from periodic to on-change, or the other way around")
+		return &restconfError
+	}
+	if filtered {
+		subscription.XPathFilter = modified.XPathFilter
+		subscription.SubtreeFilter = modified.SubtreeFilter
+		subscription.filter = modified.filter
+	}
+	if input.Input.OnChange != nil {
+		subscription.DampeningPeriod = input.Input.OnChange.DampeningPeriod
+	}
+	if periodic := input.Input.Periodic; periodic != nil {
+		subscriptionCenter.stopTimer(subscription)
+		subscription.Period = periodic.Period
+		subscription.AnchorTime = periodic.AnchorTime
+		subscriptionCenter.startTimer(id, subscription)
+	}
+	if input.Input.StopTime != "" {
+		subscription.StopTime = input.Input.StopTime
+		subscriptionCenter.scheduleStopTime(id, subscription)
+	}
+	subscription.m.Unlock()
+	_ = subscriptionCenter.Save()
+	subscriptionCenter.Send(openapi.NewSubscriptionStateNotification(openapi.SubscriptionModified, subscription.state(id)))
+	return nil
+}
+
+// Kill terminates a subscription of any client, telling its receivers.
+func (subscriptionCenter *SubscriptionCenter) Kill(id uint32) bool {
+	if !subscriptionCenter.Exists(id) {
+		return false
+	}
+	subscriptionCenter.Send(openapi.NewSubscriptionStateNotification(openapi.SubscriptionTerminated, openapi.SubscriptionState{
+		ID:     id,
+		Reason: openapi.ReasonNoSuchSubscription,
+	}))
+	return subscriptionCenter.Delete(id)
+}
+
+// Suspend stops sending the updates of a subscription for the reason, until it is resumed.
+func (subscriptionCenter *SubscriptionCenter) Suspend(id uint32, reason string) bool {
+	subscription := subscriptionCenter.lookup(id)
+	if subscription == nil {
+		return false
+	}
+	subscription.m.Lock()
+	subscription.suspended = true
+	subscription.dampened = nil
+	subscription.m.Unlock()
+	subscriptionCenter.Send(openapi.NewSubscriptionStateNotification(openapi.SubscriptionSuspended, openapi.SubscriptionState{ID: id, Reason: reason}))
+	return true
+}
+
+// Resume sends the updates of a suspended subscription again. As the changes made in the meantime were not sent,
+// an on-change subscription sends the content of the datastore first.
+func (subscriptionCenter *SubscriptionCenter) Resume(id uint32) bool {
+	subscription := subscriptionCenter.lookup(id)
+	if subscription == nil {
+		return false
+	}
+	subscription.m.Lock()
+	suspended := subscription.suspended
+	subscription.suspended = false
+	subscription.m.Unlock()
+	if !suspended {
+		return true
+	}
+	subscriptionCenter.Send(openapi.NewSubscriptionStateNotification(openapi.SubscriptionResumed, openapi.SubscriptionState{ID: id}))
+	if !subscription.periodic() {
+		err := subscriptionCenter.pushUpdate(id, subscription)
+		if err != nil {
+			log.Printf("push-update of subscription %v failed: %v", id, err)
+		}
+	}
+	return true
+}
+
+// scheduleStopTime completes the subscription at its stop time, if any.
+// The caller must hold the lock of the subscription.
+func (subscriptionCenter *SubscriptionCenter) scheduleStopTime(id uint32, subscription *subscription) {
+	if subscription.stopTimeTimer != nil {
+		subscription.stopTimeTimer.Stop()
+		subscription.stopTimeTimer = nil
+	}
+	stopTime, err := time.Parse(time.RFC3339, subscription.StopTime)
+	if err != nil {
+		return
+	}
+	subscription.stopTimeTimer = time.AfterFunc(time.Until(stopTime), func() {
+		if subscriptionCenter.lookup(id) != subscription {
+			return
+		}
+		subscriptionCenter.Send(openapi.NewSubscriptionStateNotification(openapi.SubscriptionCompleted, openapi.SubscriptionState{ID: id}))
+		subscriptionCenter.Delete(id)
+	})
+}
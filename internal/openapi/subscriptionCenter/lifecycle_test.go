@@ -0,0 +1,198 @@
+package subscriptionCenter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionCenter_Modify_OwnedSubscription_SubscriptionModifiedSent(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	syncOnStart := false
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{SyncOnStart: &syncOnStart})
+	notifications := stream(t, subscriptionCenter, id)
+	input := openapi.ModifySubscriptionInput{}
+	input.Input.ID = id
+	input.Input.DatastoreXPathFilter = "/example:counter"
+
+	restconfError := subscriptionCenter.Modify(input, "controller")
+
+	assert.Nil(t, restconfError)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started":{"id":1`)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-modified":{"id":1,"ietf-yang-push:datastore-xpath-filter":"/example:counter"`)
+}
+
+func TestSubscriptionCenter_Modify_OtherOwner_NoSuchSubscription(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{})
+	input := openapi.ModifySubscriptionInput{}
+	input.Input.ID = id
+	input.Input.DatastoreXPathFilter = "/example:counter"
+
+	restconfError := subscriptionCenter.Modify(input, "other")
+
+	if assert.NotNil(t, restconfError) {
+		assert.Equal(t, openapi.NoSuchSubscriptionError(), *restconfError)
+	}
+	assert.Empty(t, subscriptionCenter.subscriptions[id].XPathFilter)
+}
+
+func TestSubscriptionCenter_Kill_Subscription_TerminatedSentAndDeleted(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	syncOnStart := false
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{SyncOnStart: &syncOnStart})
+	notifications := stream(t, subscriptionCenter, id)
+
+	killed := subscriptionCenter.Kill(id)
+
+	assert.True(t, killed)
+	assert.False(t, subscriptionCenter.Exists(id))
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-terminated":{"id":1,"reason":"ietf-subscribed-notifications:no-such-subscription"}`)
+}
+
+func TestSubscriptionCenter_Unsubscribe_GivenOwner_DeletedByOwnerOnly(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{})
+
+	deletedByOther := subscriptionCenter.Unsubscribe(id, "other")
+	existsAfterOther := subscriptionCenter.Exists(id)
+	deletedByOwner := subscriptionCenter.Unsubscribe(id, "controller")
+
+	assert.False(t, deletedByOther)
+	assert.True(t, existsAfterOther)
+	assert.True(t, deletedByOwner)
+	assert.False(t, subscriptionCenter.Exists(id))
+}
+
+func TestSubscriptionCenter_SuspendAndResume_ChangesHeldBackThenPushUpdateSent(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	withDatastore(subscriptionCenter, `{"example:counter":2}`)
+	syncOnStart := false
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{SyncOnStart: &syncOnStart})
+	notifications := stream(t, subscriptionCenter, id)
+
+	suspended := subscriptionCenter.Suspend(id, openapi.ReasonInsufficientResources)
+	err := subscriptionCenter.Publish(counterVersion(1), counterVersion(2), "")
+	resumed := subscriptionCenter.Resume(id)
+
+	assert.True(t, suspended)
+	assert.NoError(t, err)
+	assert.True(t, resumed)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-suspended":{"id":1,"reason":"`+openapi.ReasonInsufficientResources+`"}`)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-resumed":{"id":1}`)
+	assert.Contains(t, next(t, notifications), `"ietf-yang-push:push-update":{"subscription-id":1,"datastore-contents":{"example:counter":2}}`)
+}
+
+func TestSubscriptionCenter_Subscribe_StopTime_CompletedAndDeleted(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	input := openapi.EstablishSubscriptionInput{}
+	input.Input.StopTime = time.Now().Add(2 * time.Second).Format(time.RFC3339Nano)
+	output, restconfError := subscriptionCenter.Subscribe(input, "controller")
+	if restconfError != nil {
+		t.Fatal(restconfError.ErrorMessage)
+	}
+	notifications := stream(t, subscriptionCenter, output.ID)
+
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-completed":{"id":1}`)
+	waitFor(t, func() bool { return !subscriptionCenter.Exists(output.ID) })
+}
+
+func TestSubscriptionCenter_Subscribe_StopTimeInThePast_CompletedAtOnce(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	input := openapi.EstablishSubscriptionInput{}
+	input.Input.StopTime = time.Now().Add(-time.Minute).Format(time.RFC3339)
+
+	output, restconfError := subscriptionCenter.Subscribe(input, "controller")
+
+	assert.Nil(t, restconfError)
+	waitFor(t, func() bool { return !subscriptionCenter.Exists(output.ID) })
+}
+
+// withRetainedEvents sets the log of the events retained since start, setting the counter to each value
+// at the given times.
+func withRetainedEvents(t *testing.T, subscriptionCenter *SubscriptionCenter, start time.Time, times []time.Time) {
+	log := newEventLog(defaultEventLogSize, "")
+	log.Start = start
+	for i, eventTime := range times {
+		changes, err := database.Diff(counterVersion(i), counterVersion(i+1), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log.Records = append(log.Records, eventRecord{EventTime: eventTime, Changes: changes})
+	}
+	subscriptionCenter.eventLog = log
+}
+
+func replaySubscription(t *testing.T, subscriptionCenter *SubscriptionCenter, replayStartTime time.Time) (openapi.EstablishSubscriptionOutput, <-chan string) {
+	input := openapi.EstablishSubscriptionInput{}
+	input.Input.OnChange = &openapi.OnChange{}
+	input.Input.ReplayStartTime = replayStartTime.Format(time.RFC3339)
+	output, restconfError := subscriptionCenter.Subscribe(input, "controller")
+	if restconfError != nil {
+		t.Fatal(restconfError.ErrorMessage)
+	}
+	return output, stream(t, subscriptionCenter, output.ID)
+}
+
+func TestSubscriptionCenter_Connect_ReplayStartTime_LaterEventsReplayedBeforeReplayCompleted(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	withDatastore(subscriptionCenter, `{"example:counter":2}`)
+	now := time.Now().UTC().Truncate(time.Second)
+	withRetainedEvents(t, subscriptionCenter, now.Add(-3*time.Hour), []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)})
+
+	output, notifications := replaySubscription(t, subscriptionCenter, now.Add(-90*time.Minute))
+
+	assert.Empty(t, output.ReplayStartTimeRevision)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	replayed := next(t, notifications)
+	assert.Contains(t, replayed, `"eventTime":"`+openapi.FormatEventTime(now.Add(-time.Hour))+`"`)
+	assert.Contains(t, replayed, `"value":{"example:counter":2}`)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:replay-completed":{"id":1}`)
+	assert.Contains(t, next(t, notifications), `"ietf-yang-push:push-update"`)
+}
+
+func TestSubscriptionCenter_Subscribe_ReplayStartTimeBeforeLog_RevisedAndRetainedEventsReplayed(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	now := time.Now().UTC().Truncate(time.Second)
+	start := now.Add(-3 * time.Hour)
+	withRetainedEvents(t, subscriptionCenter, start, []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)})
+
+	output, notifications := replaySubscription(t, subscriptionCenter, now.Add(-24*time.Hour))
+
+	assert.Equal(t, start.Format(time.RFC3339Nano), output.ReplayStartTimeRevision)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	assert.Contains(t, next(t, notifications), `"value":{"example:counter":1}`)
+	assert.Contains(t, next(t, notifications), `"value":{"example:counter":2}`)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:replay-completed"`)
+}
+
+func TestSubscriptionCenter_Subscribe_ReplayWithoutEventLog_ReplayUnsupportedError(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	subscriptionCenter.SetEventLog(0, "")
+	input := openapi.EstablishSubscriptionInput{}
+	input.Input.ReplayStartTime = time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	_, restconfError := subscriptionCenter.Subscribe(input, "controller")
+	replaySupport, _ := subscriptionCenter.ReplayLog()
+
+	assert.False(t, replaySupport)
+	if assert.NotNil(t, restconfError) {
+		assert.Equal(t, "ietf-subscribed-notifications:replay-unsupported", restconfError.ErrorAppTag)
+	}
+}
@@ -0,0 +1,74 @@
+package subscriptionCenter
+
+import (
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/spyzhov/ajson"
+	"log"
+	"time"
+)
+
+// dampenedChanges spans the versions of the datastore changed during the dampening period of a subscription.
+type dampenedChanges struct {
+	previous *ajson.Node
+	after    *ajson.Node
+}
+
+// notifyChanges sends the changes to an on-change subscription. Within the dampening period following
+// the last push-change-update, the changes are buffered, to be sent at once when the period ends.
+func (subscriptionCenter *SubscriptionCenter) notifyChanges(id uint32, subscription *subscription, changes []database.Change, previous *ajson.Node, after *ajson.Node) error {
+	subscription.m.Lock()
+	defer subscription.m.Unlock()
+	if subscription.suspended {
+		return nil
+	}
+	if subscription.dampened != nil {
+		subscription.dampened.after = after
+		return nil
+	}
+	dampeningPeriod := time.Duration(subscription.DampeningPeriod) * 10 * time.Millisecond
+	if wait := time.Until(subscription.lastUpdate.Add(dampeningPeriod)); wait > 0 {
+		subscription.dampened = &dampenedChanges{previous: previous, after: after}
+		time.AfterFunc(wait, func() {
+			err := subscriptionCenter.flushDampened(id, subscription)
+			if err != nil {
+				log.Printf("push-change-update of subscription %v failed: %v", id, err)
+			}
+		})
+		return nil
+	}
+	return subscriptionCenter.sendChanges(id, subscription, changes, previous, after)
+}
+
+// flushDampened sends the changes buffered during the dampening period, coalesced into the changes
+// between the versions of the datastore before and after the period.
+func (subscriptionCenter *SubscriptionCenter) flushDampened(id uint32, subscription *subscription) error {
+	subscription.m.Lock()
+	defer subscription.m.Unlock()
+	dampened := subscription.dampened
+	subscription.dampened = nil
+	if dampened == nil || subscription.suspended {
+		return nil
+	}
+	changes, err := database.Diff(dampened.previous, dampened.after, subscriptionCenter.schema)
+	if err != nil {
+		return err
+	}
+	return subscriptionCenter.sendChanges(id, subscription, changes, dampened.previous, dampened.after)
+}
+
+// sendChanges sends the changes selected by the subscription in a push-change-update.
+// The caller must hold the lock of the subscription.
+func (subscriptionCenter *SubscriptionCenter) sendChanges(id uint32, subscription *subscription, changes []database.Change, previous *ajson.Node, after *ajson.Node) error {
+	selected, err := subscription.changes(changes, previous, after, subscriptionCenter.schema)
+	if err != nil || len(selected) == 0 {
+		return err
+	}
+	edits, err := yangPatchEdits(selected)
+	if err != nil {
+		return err
+	}
+	subscriptionCenter.Send(openapi.NewRestconfNotification(id, edits...))
+	subscription.lastUpdate = time.Now()
+	return nil
+}
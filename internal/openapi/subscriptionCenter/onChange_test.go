@@ -0,0 +1,174 @@
+package subscriptionCenter
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func counterVersion(value int) *ajson.Node {
+	return ajson.Must(ajson.Unmarshal([]byte(fmt.Sprintf(`{"example:counter":%d}`, value))))
+}
+
+func withDatastore(subscriptionCenter *SubscriptionCenter, content string) {
+	subscriptionCenter.SetDatastore(func() (*ajson.Node, error) {
+		return ajson.Unmarshal([]byte(content))
+	}, nil)
+}
+
+func onChangeSubscription(t *testing.T, subscriptionCenter *SubscriptionCenter, onChange openapi.OnChange) uint32 {
+	input := openapi.EstablishSubscriptionInput{}
+	input.Input.OnChange = &onChange
+	output, restconfError := subscriptionCenter.Subscribe(input, "controller")
+	if restconfError != nil {
+		t.Fatal(restconfError.ErrorMessage)
+	}
+	return output.ID
+}
+
+// stream connects a client to the subscription, and returns the JSON notifications it receives
+// once its connection is registered.
+func stream(t *testing.T, subscriptionCenter *SubscriptionCenter, id uint32) <-chan string {
+	return streamFrom(t, subscriptionCenter, id, "")
+}
+
+// streamFrom connects a client to the subscription, resuming its stream after the event having the last ID when given.
+func streamFrom(t *testing.T, subscriptionCenter *SubscriptionCenter, id uint32, lastEventID string) <-chan string {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_ = subscriptionCenter.Connect(id, 1, writer, request)
+	}))
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = response.Body.Close()
+		server.CloseClientConnections()
+		server.Close()
+	})
+
+	notifications := make(chan string, 10)
+	connected := make(chan struct{})
+	go func() {
+		heartbeats := 0
+		var once sync.Once
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, ":") {
+				// the connection is registered by the time of the second heartbeat, or of the first notification
+				if heartbeats++; heartbeats == 2 {
+					once.Do(func() { close(connected) })
+				}
+			} else if strings.HasPrefix(line, "data: ") {
+				once.Do(func() { close(connected) })
+				notifications <- strings.TrimPrefix(line, "data: ")
+			}
+		}
+		close(notifications)
+	}()
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream of the subscription is not connected")
+	}
+	return notifications
+}
+
+func next(t *testing.T, notifications <-chan string) string {
+	select {
+	case notification := <-notifications:
+		return notification
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+		return ""
+	}
+}
+
+func TestSubscriptionCenter_Connect_SyncOnStart_StartedThenPushUpdate(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	withDatastore(subscriptionCenter, `{"example:counter":1}`)
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{})
+
+	notifications := stream(t, subscriptionCenter, id)
+
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	assert.Contains(t, next(t, notifications), `"ietf-yang-push:push-update":{"subscription-id":1,"datastore-contents":{"example:counter":1}}`)
+}
+
+func TestSubscriptionCenter_Connect_NoSyncOnStart_StartedThenChanges(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	withDatastore(subscriptionCenter, `{"example:counter":1}`)
+	syncOnStart := false
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{SyncOnStart: &syncOnStart})
+	notifications := stream(t, subscriptionCenter, id)
+
+	err := subscriptionCenter.Publish(counterVersion(1), counterVersion(2), "")
+
+	assert.NoError(t, err)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	assert.Contains(t, next(t, notifications), `"ietf-yang-push:push-change-update"`)
+}
+
+func TestSubscriptionCenter_Publish_WithinDampeningPeriod_ChangesCoalesced(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	syncOnStart := false
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{DampeningPeriod: 10, SyncOnStart: &syncOnStart})
+	notifications := stream(t, subscriptionCenter, id)
+
+	for value := 0; value < 3; value++ {
+		assert.NoError(t, subscriptionCenter.Publish(counterVersion(value), counterVersion(value+1), ""))
+	}
+
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	first := next(t, notifications)
+	coalesced := next(t, notifications)
+	assert.Contains(t, first, `"value":{"example:counter":1}`)
+	assert.Contains(t, coalesced, `"value":{"example:counter":3}`)
+	assert.Equal(t, 1, strings.Count(coalesced, `"edit-id"`))
+	select {
+	case notification := <-notifications:
+		t.Errorf("unexpected notification %v", notification)
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestSubscriptionCenter_Publish_ExcludedChange_LeftOutOfUpdate(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	syncOnStart := false
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{SyncOnStart: &syncOnStart, ExcludedChange: []string{"create"}})
+	notifications := stream(t, subscriptionCenter, id)
+
+	err := subscriptionCenter.Publish(
+		ajson.Must(ajson.Unmarshal([]byte(`{"example:counter":1}`))),
+		ajson.Must(ajson.Unmarshal([]byte(`{"example:counter":2,"example:label":"new"}`))),
+		"",
+	)
+
+	assert.NoError(t, err)
+	assert.Contains(t, next(t, notifications), `"ietf-subscribed-notifications:subscription-started"`)
+	notification := next(t, notifications)
+	assert.Contains(t, notification, `"value":{"example:counter":2}`)
+	assert.NotContains(t, notification, `"operation":"create"`)
+	assert.NotContains(t, notification, `example:label`)
+}
@@ -0,0 +1,123 @@
+package subscriptionCenter
+
+import (
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/spyzhov/ajson"
+	"log"
+	"time"
+)
+
+// Datastore reads the current content of the datastore the subscriptions are made to.
+type Datastore func() (*ajson.Node, error)
+
+// SetDatastore sets the datastore the periodic subscriptions push the content of, described by schema,
+// and starts the timers of the periodic subscriptions restored from the previous run.
+func (subscriptionCenter *SubscriptionCenter) SetDatastore(datastore Datastore, schema *openapi3.Schema) {
+	subscriptionCenter.datastore = datastore
+	subscriptionCenter.schema = schema
+	for id, subscription := range subscriptionCenter.snapshot() {
+		subscription.m.Lock()
+		if subscription.Period > 0 && subscription.stop == nil {
+			subscriptionCenter.startTimer(id, subscription)
+		}
+		if subscription.stopTimeTimer == nil {
+			subscriptionCenter.scheduleStopTime(id, subscription)
+		}
+		subscription.m.Unlock()
+	}
+}
+
+// startTimer sends the push-update notifications of the periodic subscription until it is deleted,
+// from the time given by nextUpdate. The caller must hold the lock of the subscription.
+func (subscriptionCenter *SubscriptionCenter) startTimer(id uint32, subscription *subscription) {
+	period := time.Duration(subscription.Period) * 10 * time.Millisecond
+	next := nextUpdate(time.Now(), period, subscription.AnchorTime)
+	stop := make(chan struct{})
+	subscription.stop = stop
+	go func() {
+		timer := time.NewTimer(time.Until(next))
+		defer timer.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
+				err := subscriptionCenter.pushUpdate(id, subscription)
+				if err != nil {
+					log.Printf("push-update of subscription %v failed: %v", id, err)
+				}
+				next = next.Add(period)
+				timer.Reset(time.Until(next))
+			}
+		}
+	}()
+}
+
+// nextUpdate returns the time of the first update following now. The updates are sent at the anchor time
+// plus or minus a multiple of the period, or every period from now without anchor time.
+func nextUpdate(now time.Time, period time.Duration, anchorTime string) time.Time {
+	anchor, err := time.Parse(time.RFC3339, anchorTime)
+	if err != nil {
+		return now.Add(period)
+	}
+	next := anchor.Add(now.Sub(anchor).Truncate(period))
+	for !next.After(now) {
+		next = next.Add(period)
+	}
+	return next
+}
+
+// stopTimer stops the timer of a periodic subscription. The caller must hold the lock of the subscription.
+func (subscriptionCenter *SubscriptionCenter) stopTimer(subscription *subscription) {
+	if subscription.stop != nil {
+		close(subscription.stop)
+		subscription.stop = nil
+	}
+}
+
+// pushUpdate sends the filtered content of the datastore to the subscription.
+func (subscriptionCenter *SubscriptionCenter) pushUpdate(id uint32, subscription *subscription) error {
+	subscription.m.Lock()
+	suspended := subscription.suspended
+	subscription.m.Unlock()
+	if suspended {
+		return nil
+	}
+	notification, err := subscriptionCenter.pushUpdateNotification(id, subscription)
+	if err != nil || notification == nil {
+		return err
+	}
+	subscriptionCenter.Send(*notification)
+	return nil
+}
+
+// pushUpdateNotification builds the push-update notification holding the filtered content of the datastore,
+// or returns nil without datastore.
+func (subscriptionCenter *SubscriptionCenter) pushUpdateNotification(id uint32, subscription *subscription) (*openapi.RestconfNotification, error) {
+	if subscriptionCenter.datastore == nil {
+		return nil, nil
+	}
+	content, err := subscriptionCenter.datastore()
+	if err != nil {
+		return nil, err
+	}
+	subscription.m.Lock()
+	filter := subscription.filter
+	subscription.m.Unlock()
+	if filter != nil {
+		content, err = filter.Select(content, subscriptionCenter.schema)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var contents interface{}
+	if content != nil {
+		contents, err = content.Unpack()
+		if err != nil {
+			return nil, err
+		}
+	}
+	notification := openapi.NewPushUpdateNotification(id, contents)
+	return &notification, nil
+}
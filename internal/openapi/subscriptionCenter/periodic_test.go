@@ -0,0 +1,94 @@
+package subscriptionCenter
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/spyzhov/ajson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextUpdate_GivenAnchorTime_AlignedOnAnchorTime(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		anchorTime string
+		expected   time.Time
+	}{
+		{"no anchor time", "", now.Add(10 * time.Second)},
+		{"anchor time in the past", "2021-06-01T11:59:53Z", now.Add(3 * time.Second)},
+		{"anchor time in the future", "2021-06-01T12:00:24Z", now.Add(4 * time.Second)},
+		{"anchor time on an update", "2021-06-01T11:00:00Z", now.Add(10 * time.Second)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			next := nextUpdate(now, 10*time.Second, test.anchorTime)
+
+			assert.Equal(t, test.expected, next)
+		})
+	}
+}
+
+// countingDatastore counts the reads of the datastore, made by every push-update.
+func countingDatastore(subscriptionCenter *SubscriptionCenter) *int32 {
+	var reads int32
+	subscriptionCenter.SetDatastore(func() (*ajson.Node, error) {
+		atomic.AddInt32(&reads, 1)
+		return ajson.Must(ajson.Unmarshal([]byte(`{"example:counter":0}`))), nil
+	}, nil)
+	return &reads
+}
+
+func periodicSubscription(t *testing.T, subscriptionCenter *SubscriptionCenter) uint32 {
+	input := openapi.EstablishSubscriptionInput{}
+	input.Input.Periodic = &openapi.Periodic{Period: 2}
+	output, restconfError := subscriptionCenter.Subscribe(input, "controller")
+	if restconfError != nil {
+		t.Fatal(restconfError.ErrorMessage)
+	}
+	return output.ID
+}
+
+func TestSubscriptionCenter_Subscribe_Periodic_UpdatesPushedEveryPeriod(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	reads := countingDatastore(subscriptionCenter)
+	id := periodicSubscription(t, subscriptionCenter)
+	defer subscriptionCenter.Delete(id)
+
+	waitFor(t, func() bool { return atomic.LoadInt32(reads) >= 3 })
+}
+
+func TestSubscriptionCenter_Delete_Periodic_TimerStopped(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	reads := countingDatastore(subscriptionCenter)
+	id := periodicSubscription(t, subscriptionCenter)
+	waitFor(t, func() bool { return atomic.LoadInt32(reads) >= 1 })
+
+	deleted := subscriptionCenter.Delete(id)
+	time.Sleep(50 * time.Millisecond)
+	afterDelete := atomic.LoadInt32(reads)
+	time.Sleep(100 * time.Millisecond)
+
+	assert.True(t, deleted)
+	assert.Equal(t, afterDelete, atomic.LoadInt32(reads))
+}
+
+func TestSubscriptionCenter_Kill_Periodic_TimerStopped(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	reads := countingDatastore(subscriptionCenter)
+	id := periodicSubscription(t, subscriptionCenter)
+	waitFor(t, func() bool { return atomic.LoadInt32(reads) >= 1 })
+
+	killed := subscriptionCenter.Kill(id)
+	time.Sleep(50 * time.Millisecond)
+	afterKill := atomic.LoadInt32(reads)
+	time.Sleep(100 * time.Millisecond)
+
+	assert.True(t, killed)
+	assert.Equal(t, afterKill, atomic.LoadInt32(reads))
+}
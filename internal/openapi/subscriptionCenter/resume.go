@@ -0,0 +1,62 @@
+package subscriptionCenter
+
+import (
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"strconv"
+	"sync"
+)
+
+// eventBufferSize is the number of the latest events of a subscription kept for the clients resuming its stream.
+const eventBufferSize = 100
+
+// eventBuffer numbers the events sent to the clients of a subscription, and keeps the latest ones.
+type eventBuffer struct {
+	m      sync.Mutex // also serializes the events sent to the clients of the subscription
+	lastID uint64
+	events []*RestconfEvent
+}
+
+// add numbers the event following the previous ones, and keeps it in the buffer.
+// The caller must hold the lock of the buffer.
+func (buffer *eventBuffer) add(event *RestconfEvent) {
+	buffer.lastID++
+	event.Id = strconv.FormatUint(buffer.lastID, 10)
+	buffer.events = append(buffer.events, event)
+	if len(buffer.events) > eventBufferSize {
+		buffer.events = append([]*RestconfEvent(nil), buffer.events[len(buffer.events)-eventBufferSize:]...)
+	}
+}
+
+// after returns the buffered events following the event having the last ID, and whether some of them were lost,
+// being dropped from the buffer or sent before the server restarted.
+// The caller must hold the lock of the buffer.
+func (buffer *eventBuffer) after(lastID uint64) (events []*RestconfEvent, lost bool) {
+	if lastID > buffer.lastID {
+		return buffer.events, true
+	}
+	firstID := buffer.lastID - uint64(len(buffer.events)) + 1
+	if lastID+1 < firstID {
+		return buffer.events, true
+	}
+	return buffer.events[lastID+1-firstID:], false
+}
+
+// resume registers a client reconnecting to the stream of the subscription, then sends it the events following
+// the last one it received, preceded by a push-change-update flagging updates-not-sent when some of them were lost.
+func (subscriptionCenter *SubscriptionCenter) resume(id uint32, clientId string, conn *connection, lastEventID uint64) {
+	subscription := subscriptionCenter.lookup(id)
+	if subscription == nil {
+		subscriptionCenter.register(id, clientId, conn)
+		return
+	}
+	subscription.events.m.Lock()
+	defer subscription.events.m.Unlock()
+	subscriptionCenter.register(id, clientId, conn)
+	events, lost := subscription.events.after(lastEventID)
+	if lost {
+		conn.send(&RestconfEvent{Data: openapi.NewUpdatesNotSentNotification(id)})
+	}
+	for _, event := range events {
+		conn.send(event)
+	}
+}
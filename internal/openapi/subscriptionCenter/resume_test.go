@@ -0,0 +1,89 @@
+package subscriptionCenter
+
+import (
+	"testing"
+
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// filledBuffer numbers the given number of events, the buffer keeping the latest ones.
+func filledBuffer(count int) *eventBuffer {
+	buffer := &eventBuffer{}
+	for i := 0; i < count; i++ {
+		buffer.add(&RestconfEvent{})
+	}
+	return buffer
+}
+
+func eventIDs(events []*RestconfEvent) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.Id
+	}
+	return ids
+}
+
+func TestEventBuffer_After_IDInBuffer_FollowingEvents(t *testing.T) {
+	buffer := filledBuffer(eventBufferSize + 20)
+
+	events, lost := buffer.after(117)
+
+	assert.False(t, lost)
+	assert.Equal(t, []string{"118", "119", "120"}, eventIDs(events))
+}
+
+func TestEventBuffer_After_LastID_NoEvents(t *testing.T) {
+	buffer := filledBuffer(5)
+
+	events, lost := buffer.after(5)
+
+	assert.False(t, lost)
+	assert.Empty(t, events)
+}
+
+func TestEventBuffer_After_IDPrecedingFirstBufferedEvent_AllEventsNotLost(t *testing.T) {
+	buffer := filledBuffer(eventBufferSize + 20)
+
+	events, lost := buffer.after(20)
+
+	assert.False(t, lost)
+	assert.Len(t, events, eventBufferSize)
+	assert.Equal(t, "21", events[0].Id)
+}
+
+func TestEventBuffer_After_IDOlderThanBuffer_AllEventsAndLost(t *testing.T) {
+	buffer := filledBuffer(eventBufferSize + 20)
+
+	events, lost := buffer.after(10)
+
+	assert.True(t, lost)
+	assert.Len(t, events, eventBufferSize)
+	assert.Equal(t, "21", events[0].Id)
+}
+
+func TestEventBuffer_After_IDBeyondLastID_AllEventsAndLost(t *testing.T) {
+	buffer := filledBuffer(3)
+
+	events, lost := buffer.after(500)
+
+	assert.True(t, lost)
+	assert.Equal(t, []string{"1", "2", "3"}, eventIDs(events))
+}
+
+func TestSubscriptionCenter_Connect_LastEventIDOfLostEvent_UpdatesNotSentThenBufferedEvents(t *testing.T) {
+	inTempDir(t)
+	subscriptionCenter := NewSubscriptionCenter()
+	id := onChangeSubscription(t, subscriptionCenter, openapi.OnChange{})
+	for i := 0; i < eventBufferSize+1; i++ {
+		subscriptionCenter.Send(openapi.NewRestconfNotification(id))
+	}
+
+	notifications := streamFrom(t, subscriptionCenter, id, "0")
+
+	assert.Contains(t, next(t, notifications), `"updates-not-sent":[null]`)
+	for i := 0; i < eventBufferSize; i++ {
+		assert.NotContains(t, next(t, notifications), `"updates-not-sent"`)
+	}
+	assert.Len(t, subscriptionCenter.connMap[id], 1)
+}
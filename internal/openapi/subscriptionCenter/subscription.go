@@ -0,0 +1,257 @@
+package subscriptionCenter
+
+import (
+	"encoding/json"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/muonsoft/openapi-mock/internal/openapi"
+	"github.com/pkg/errors"
+	"github.com/spyzhov/ajson"
+	"sync"
+	"time"
+)
+
+// subscription is a dynamic subscription established by a client.
+// It selects the changes by the XPath or subtree filter when given, by the object types otherwise.
+// Subscriptions without period are on-change subscriptions.
+type subscription struct {
+	ObjectTypes []openapi.ObjectTypeInfo
+	Owner       string // identity of the subscriber
+	subscriptionFilter
+	// Period is the number of centiseconds between the push-update notifications of a periodic subscription,
+	// which are aligned on AnchorTime when given
+	Period     uint32 `json:",omitempty"`
+	AnchorTime string `json:",omitempty"`
+	// DampeningPeriod is the minimal number of centiseconds between the push-change-update notifications
+	DampeningPeriod uint32 `json:",omitempty"`
+	// SyncOnStart sends the content of the datastore to the clients connecting to an on-change subscription
+	SyncOnStart     bool     `json:",omitempty"`
+	ExcludedChanges []string `json:",omitempty"`
+	// StopTime ends the subscription when given
+	StopTime string `json:",omitempty"`
+	// ReplayStartTime is the time of the oldest event replayed to the clients connecting to the subscription
+	ReplayStartTime string `json:",omitempty"`
+	// Encoding is the encoding of the notification messages, JSON when empty
+	Encoding string `json:",omitempty"`
+
+	events eventBuffer // the latest events sent to the clients, for those resuming the stream
+	// m guards the fields changed by modify-subscription, and the state of the notifications below
+	m             sync.Mutex
+	stop          chan struct{} // stops the timer of a periodic subscription
+	stopTimeTimer *time.Timer
+	suspended     bool
+	lastUpdate    time.Time
+	dampened      *dampenedChanges
+}
+
+// subscriptionFilter is the XPath or subtree filter of a subscription.
+type subscriptionFilter struct {
+	XPathFilter   string          `json:",omitempty"`
+	SubtreeFilter json.RawMessage `json:",omitempty"`
+	filter        database.Filter
+}
+
+// savedSubscription is read without the UnmarshalJSON method of subscription.
+type savedSubscription subscription
+
+// UnmarshalJSON reads the subscriptions saved as the list of their object types as well.
+func (subscription *subscription) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, &subscription.ObjectTypes)
+	}
+	err := json.Unmarshal(data, (*savedSubscription)(subscription))
+	if err != nil {
+		return err
+	}
+	return subscription.parseFilter()
+}
+
+func (subscription *subscription) MarshalJSON() ([]byte, error) {
+	subscription.m.Lock()
+	defer subscription.m.Unlock()
+	return json.Marshal((*savedSubscription)(subscription))
+}
+
+func (subscriptionFilter *subscriptionFilter) parseFilter() (err error) {
+	if string(subscriptionFilter.SubtreeFilter) == "null" {
+		subscriptionFilter.SubtreeFilter = nil
+	}
+	switch {
+	case subscriptionFilter.XPathFilter != "" && len(subscriptionFilter.SubtreeFilter) > 0:
+		return errors.New("Only one of the XPath and subtree filters can be given")
+	case subscriptionFilter.XPathFilter != "":
+		subscriptionFilter.filter, err = database.ParseXPathFilter(subscriptionFilter.XPathFilter)
+	case len(subscriptionFilter.SubtreeFilter) > 0:
+		var filter *ajson.Node
+		filter, err = ajson.Unmarshal(subscriptionFilter.SubtreeFilter)
+		if err == nil {
+			subscriptionFilter.filter = database.NewSubtreeFilter(filter)
+		}
+	}
+	return err
+}
+
+// stopTimers stops the timers of the stop time and of the dampening period of a deleted subscription,
+// which sends nothing anymore. The caller must hold the lock of the subscription.
+func (subscription *subscription) stopTimers() {
+	if subscription.stopTimeTimer != nil {
+		subscription.stopTimeTimer.Stop()
+		subscription.stopTimeTimer = nil
+	}
+	subscription.dampened = nil
+	subscription.suspended = true
+}
+
+// periodic tells whether the subscription sends push-update notifications periodically, rather than on change.
+func (subscription *subscription) periodic() bool {
+	subscription.m.Lock()
+	defer subscription.m.Unlock()
+	return subscription.Period > 0
+}
+
+// encoding returns the encoding of the notification messages, the subscriptions saved before it was chosen
+// being encoded in JSON.
+func (subscription *subscription) encoding() string {
+	if subscription.Encoding == "" {
+		return openapi.EncodeJSON
+	}
+	return subscription.Encoding
+}
+
+// validatePeriodic checks the options of a periodic subscription.
+func validatePeriodic(periodic *openapi.Periodic) *openapi.RestconfError {
+	if periodic.Period == 0 {
+		restconfError := openapi.PeriodUnsupportedError("The period must be greater than zero")
+		return &restconfError
+	}
+	if _, err := time.Parse(time.RFC3339, periodic.AnchorTime); periodic.AnchorTime != "" && err != nil {
+		restconfError := openapi.InvalidValueError("", "Invalid anchor-time '"+periodic.AnchorTime+"'")
+		return &restconfError
+	}
+	return nil
+}
+
+func validateStopTime(stopTime string) *openapi.RestconfError {
+	if _, err := time.Parse(time.RFC3339, stopTime); stopTime != "" && err != nil {
+		restconfError := openapi.InvalidValueError("", "Invalid stop-time '"+stopTime+"'")
+		return &restconfError
+	}
+	return nil
+}
+
+// state describes the subscription in the notifications of the changes of its state.
+func (subscription *subscription) state(id uint32) openapi.SubscriptionState {
+	subscription.m.Lock()
+	defer subscription.m.Unlock()
+	state := openapi.SubscriptionState{
+		ID:                     id,
+		StopTime:               subscription.StopTime,
+		DatastoreXPathFilter:   subscription.XPathFilter,
+		DatastoreSubtreeFilter: subscription.SubtreeFilter,
+	}
+	if subscription.Period > 0 {
+		state.Periodic = &openapi.Periodic{Period: subscription.Period, AnchorTime: subscription.AnchorTime}
+	} else {
+		syncOnStart := subscription.SyncOnStart
+		state.OnChange = &openapi.OnChange{
+			DampeningPeriod: subscription.DampeningPeriod,
+			SyncOnStart:     &syncOnStart,
+			ExcludedChange:  subscription.ExcludedChanges,
+		}
+	}
+	return state
+}
+
+// excludes tells whether the changes of the operation are not notified by the subscription.
+func (subscription *subscription) excludes(operation string) bool {
+	for _, excluded := range subscription.ExcludedChanges {
+		if excluded == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// changes returns the changes between two versions of running which the subscription selects.
+// The changes of the filtered versions are computed again, as the filter narrows down the values of the edits.
+func (subscription *subscription) changes(changes []database.Change, previous *ajson.Node, after *ajson.Node, schema *openapi3.Schema) ([]database.Change, error) {
+	if subscription.filter != nil {
+		previousSelection, err := subscription.filter.Select(previous, schema)
+		if err != nil {
+			return nil, err
+		}
+		afterSelection, err := subscription.filter.Select(after, schema)
+		if err != nil {
+			return nil, err
+		}
+		changes, err = database.Diff(previousSelection, afterSelection, schema)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var selected []database.Change
+	for _, change := range changes {
+		if subscription.selects(change) {
+			selected = append(selected, change)
+		}
+	}
+	return selected, nil
+}
+
+// selects tells whether the change is of a type the subscription does not exclude,
+// and of one of its object types when the subscription is not filtered.
+func (subscription *subscription) selects(change database.Change) bool {
+	if subscription.excludes(change.Operation) {
+		return false
+	}
+	if subscription.filter == nil && len(subscription.ObjectTypes) > 0 {
+		objectType, ok := targetObjectType(change.Target)
+		return ok && containsObjectType(subscription.ObjectTypes, objectType)
+	}
+	return true
+}
+
+// replayedChanges returns the changes of a past event which the subscription selects.
+// Unlike the changes being notified, the changes are replayed as a whole when their content matches the filter.
+func (subscription *subscription) replayedChanges(changes []database.Change, schema *openapi3.Schema) ([]database.Change, error) {
+	subscription.m.Lock()
+	defer subscription.m.Unlock()
+	var selected []database.Change
+	for _, change := range changes {
+		if !subscription.selects(change) {
+			continue
+		}
+		if subscription.filter != nil {
+			content, err := change.Content(schema)
+			if err != nil {
+				return nil, err
+			}
+			selection, err := subscription.filter.Select(content, schema)
+			if err != nil {
+				return nil, err
+			}
+			if selection == nil {
+				continue
+			}
+		}
+		selected = append(selected, change)
+	}
+	return selected, nil
+}
+
+// validateReplayStartTime checks the replay-start-time of a subscription, which cannot be in the future.
+// Replay is only supported by on-change subscriptions.
+func validateReplayStartTime(replayStartTime string, periodic bool) *openapi.RestconfError {
+	if replayStartTime == "" {
+		return nil
+	}
+	if periodic {
+		restconfError := openapi.ReplayUnsupportedError("Periodic subscriptions cannot replay events")
+		return &restconfError
+	}
+	if start, err := time.Parse(time.RFC3339, replayStartTime); err != nil || start.After(time.Now()) {
+		restconfError := openapi.InvalidValueError("", "Invalid replay-start-time '"+replayStartTime+"'")
+		return &restconfError
+	}
+	return nil
+}
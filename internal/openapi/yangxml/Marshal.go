@@ -0,0 +1,186 @@
+package yangxml
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// Marshal encodes JSON encoded YANG data (RFC 7951) into XML (RFC 7950 section 7).
+// Module-qualified member names become elements in the namespace of the module,
+// and metadata annotations such as "@leaf" become attributes of the annotated element.
+// An object holding several top level members is wrapped in the RESTCONF "data" element.
+func Marshal(data interface{}, namespaces Namespaces) ([]byte, error) {
+	normalized, err := normalize(data)
+	if err != nil {
+		return nil, err
+	}
+	object, isObject := normalized.(map[string]interface{})
+	if !isObject {
+		return nil, errors.New("only objects can be encoded as YANG XML")
+	}
+	encoder := &encoder{namespaces: namespaces}
+	if countMembers(object) == 1 {
+		err = encoder.encodeMembers(object, "")
+	} else {
+		encoder.buffer.WriteString(`<data xmlns="` + RestconfNamespace + `">`)
+		err = encoder.encodeMembers(object, RestconfNamespace)
+		encoder.buffer.WriteString(`</data>`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return encoder.buffer.Bytes(), nil
+}
+
+type encoder struct {
+	namespaces Namespaces
+	buffer     bytes.Buffer
+}
+
+func (encoder *encoder) encodeMembers(object map[string]interface{}, parentNamespace string) error {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		if !strings.HasPrefix(name, "@") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		namespace := parentNamespace
+		module, local := splitName(name)
+		if module != "" {
+			namespace = encoder.namespaces.Namespace(module)
+		}
+		annotations := object["@"+name]
+		entries, isArray := object[name].([]interface{})
+		if !isArray || isEmptyLeaf(entries) {
+			err := encoder.encodeElement(local, namespace, parentNamespace, object[name], annotations)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		entryAnnotations, _ := annotations.([]interface{})
+		for i, entry := range entries {
+			var entryAnnotation interface{}
+			if i < len(entryAnnotations) {
+				entryAnnotation = entryAnnotations[i]
+			}
+			err := encoder.encodeElement(local, namespace, parentNamespace, entry, entryAnnotation)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (encoder *encoder) encodeElement(local string, namespace string, parentNamespace string, value interface{}, annotations interface{}) error {
+	encoder.buffer.WriteString("<" + local)
+	if namespace != parentNamespace && namespace != "" {
+		encoder.writeAttribute("xmlns", namespace)
+	}
+	if attributes, ok := annotations.(map[string]interface{}); ok {
+		encoder.encodeAnnotations(attributes)
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		// the annotations of a container or list entry are held by its "@" member (RFC 7952 section 5.2.1)
+		if attributes, ok := typed["@"].(map[string]interface{}); ok {
+			encoder.encodeAnnotations(attributes)
+		}
+		encoder.buffer.WriteString(">")
+		err := encoder.encodeMembers(typed, namespace)
+		if err != nil {
+			return err
+		}
+	case []interface{}:
+		// leaf of type empty, encoded as [null]
+		encoder.buffer.WriteString("/>")
+		return nil
+	case nil:
+		encoder.buffer.WriteString("/>")
+		return nil
+	default:
+		encoder.buffer.WriteString(">")
+		err := xml.EscapeText(&encoder.buffer, []byte(scalarText(typed)))
+		if err != nil {
+			return err
+		}
+	}
+	encoder.buffer.WriteString("</" + local + ">")
+	return nil
+}
+
+func (encoder *encoder) encodeAnnotations(annotations map[string]interface{}) {
+	names := make([]string, 0, len(annotations))
+	for name := range annotations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		module, local := splitName(name)
+		if module == "" {
+			encoder.writeAttribute(local, scalarText(annotations[name]))
+			continue
+		}
+		encoder.writeAttribute("xmlns:"+module, encoder.namespaces.Namespace(module))
+		encoder.writeAttribute(module+":"+local, scalarText(annotations[name]))
+	}
+}
+
+func (encoder *encoder) writeAttribute(name string, value string) {
+	encoder.buffer.WriteString(" " + name + `="`)
+	_ = xml.EscapeText(&encoder.buffer, []byte(value))
+	encoder.buffer.WriteString(`"`)
+}
+
+func scalarText(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case json.Number:
+		return typed.String()
+	case bool:
+		if typed {
+			return "true"
+		}
+		return "false"
+	case nil:
+		return ""
+	default:
+		marshal, _ := json.Marshal(typed)
+		return string(marshal)
+	}
+}
+
+func isEmptyLeaf(entries []interface{}) bool {
+	return len(entries) == 1 && entries[0] == nil
+}
+
+func countMembers(object map[string]interface{}) int {
+	count := 0
+	for name := range object {
+		if !strings.HasPrefix(name, "@") {
+			count++
+		}
+	}
+	return count
+}
+
+// normalize converts data into generic JSON values, keeping numbers as they are written.
+func normalize(data interface{}) (interface{}, error) {
+	marshal, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(marshal))
+	decoder.UseNumber()
+	var normalized interface{}
+	err = decoder.Decode(&normalized)
+	return normalized, err
+}
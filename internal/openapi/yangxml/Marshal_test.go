@@ -0,0 +1,65 @@
+package yangxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal_ModuleQualifiedData_ElementsInModuleNamespaces(t *testing.T) {
+	data := map[string]interface{}{
+		"example-jukebox:jukebox": map[string]interface{}{
+			"library": map[string]interface{}{
+				"artist": []interface{}{
+					map[string]interface{}{"name": "Foo Fighters", "album-count": 3},
+					map[string]interface{}{"name": "Nick Cave"},
+				},
+				"example-augment:shuffle": true,
+			},
+		},
+	}
+
+	xml, err := Marshal(data, Namespaces{"example-jukebox": "http://example.com/ns/example-jukebox"})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`<jukebox xmlns="http://example.com/ns/example-jukebox"><library>`+
+			`<artist><album-count>3</album-count><name>Foo Fighters</name></artist><artist><name>Nick Cave</name></artist>`+
+			`<shuffle xmlns="urn:ietf:params:xml:ns:yang:example-augment">true</shuffle>`+
+			`</library></jukebox>`,
+		string(xml))
+}
+
+func TestMarshal_AnnotatedLeaf_AttributeWritten(t *testing.T) {
+	data := map[string]interface{}{
+		"example:player": map[string]interface{}{
+			"gap":  0.5,
+			"@gap": map[string]interface{}{"ietf-netconf-with-defaults:default": true},
+		},
+	}
+
+	xml, err := Marshal(data, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`<player xmlns="urn:ietf:params:xml:ns:yang:example">`+
+			`<gap xmlns:ietf-netconf-with-defaults="urn:ietf:params:xml:ns:netconf:default:1.0" ietf-netconf-with-defaults:default="true">0.5</gap>`+
+			`</player>`,
+		string(xml))
+}
+
+func TestMarshal_SeveralTopLevelNodes_WrappedInDataElement(t *testing.T) {
+	data := map[string]interface{}{
+		"a:x": "1 < 2",
+		"b:y": []interface{}{nil},
+	}
+
+	xml, err := Marshal(data, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`<data xmlns="urn:ietf:params:xml:ns:yang:ietf-restconf">`+
+			`<x xmlns="urn:ietf:params:xml:ns:yang:a">1 &lt; 2</x><y xmlns="urn:ietf:params:xml:ns:yang:b"/>`+
+			`</data>`,
+		string(xml))
+}
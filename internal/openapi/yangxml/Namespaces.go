@@ -0,0 +1,56 @@
+package yangxml
+
+import "strings"
+
+const ietfNamespacePrefix = "urn:ietf:params:xml:ns:yang:"
+
+// RestconfNamespace is the namespace of the RESTCONF "data" and "errors" elements.
+const RestconfNamespace = ietfNamespacePrefix + "ietf-restconf"
+
+// wellKnownNamespaces lists the modules whose XML namespace does not follow the IETF convention.
+var wellKnownNamespaces = map[string]string{
+	"ietf-netconf":               "urn:ietf:params:xml:ns:netconf:base:1.0",
+	"ietf-netconf-with-defaults": "urn:ietf:params:xml:ns:netconf:default:1.0",
+	"notifications":              "urn:ietf:params:xml:ns:netconf:notification:1.0",
+}
+
+// Namespaces maps YANG module names to XML namespaces.
+// Modules which are not listed use the IETF convention "urn:ietf:params:xml:ns:yang:<module>".
+type Namespaces map[string]string
+
+// Namespace returns the XML namespace of the module.
+func (namespaces Namespaces) Namespace(module string) string {
+	if namespace, ok := namespaces[module]; ok {
+		return namespace
+	}
+	if namespace, ok := wellKnownNamespaces[module]; ok {
+		return namespace
+	}
+	return ietfNamespacePrefix + module
+}
+
+// Module returns the name of the module having the XML namespace, or an empty string if it is unknown.
+func (namespaces Namespaces) Module(namespace string) string {
+	for module, candidate := range namespaces {
+		if candidate == namespace {
+			return module
+		}
+	}
+	for module, candidate := range wellKnownNamespaces {
+		if candidate == namespace {
+			return module
+		}
+	}
+	if strings.HasPrefix(namespace, ietfNamespacePrefix) {
+		return strings.TrimPrefix(namespace, ietfNamespacePrefix)
+	}
+	return ""
+}
+
+// splitName splits a JSON member name such as "ietf-interfaces:interfaces" into its module and local name.
+func splitName(name string) (module string, local string) {
+	if index := strings.Index(name, ":"); index >= 0 {
+		return name[:index], name[index+1:]
+	}
+	return "", name
+}
@@ -0,0 +1,161 @@
+package yangxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/muonsoft/openapi-mock/database"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Unmarshal decodes YANG data encoded in XML into its JSON encoding (RFC 7951).
+// The schema describes the object wrapping the top level element, it tells lists from containers and the types of leaves.
+// Elements are named after the matching schema properties, or qualified by the module of their namespace
+// when it differs from the one of their parent otherwise.
+func Unmarshal(data []byte, schema *openapi3.Schema, namespaces Namespaces) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root, err := readRoot(decoder)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	if root.XMLName.Space == RestconfNamespace && root.XMLName.Local == "data" {
+		// the RESTCONF "data" element wraps several top level nodes
+		for _, child := range root.Children {
+			addMember(result, child, schema, "", namespaces)
+		}
+		return result, nil
+	}
+	addMember(result, root, schema, "", namespaces)
+	return result, nil
+}
+
+type element struct {
+	XMLName  xml.Name
+	Text     string
+	Children []*element
+}
+
+func readRoot(decoder *xml.Decoder) (*element, error) {
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("the XML document has no element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return readElement(decoder, start)
+		}
+	}
+}
+
+func readElement(decoder *xml.Decoder, start xml.StartElement) (*element, error) {
+	current := &element{XMLName: start.Name}
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch typed := token.(type) {
+		case xml.StartElement:
+			child, err := readElement(decoder, typed)
+			if err != nil {
+				return nil, err
+			}
+			current.Children = append(current.Children, child)
+		case xml.CharData:
+			text.Write(typed)
+		case xml.EndElement:
+			current.Text = strings.TrimSpace(text.String())
+			return current, nil
+		}
+	}
+}
+
+// addMember adds the JSON encoding of the element to object, whose schema is parentSchema.
+func addMember(object map[string]interface{}, current *element, parentSchema *openapi3.Schema, parentNamespace string, namespaces Namespaces) {
+	name := memberName(current, parentSchema, parentNamespace, namespaces)
+	schema := database.ChildSchema(parentSchema, name)
+	value := elementValue(current, schema, namespaces)
+	if existing, exists := object[name]; exists {
+		if entries, isArray := existing.([]interface{}); isArray {
+			object[name] = append(entries, value)
+		} else {
+			object[name] = []interface{}{existing, value}
+		}
+		return
+	}
+	if isList(schema) {
+		object[name] = []interface{}{value}
+		return
+	}
+	object[name] = value
+}
+
+func memberName(current *element, parentSchema *openapi3.Schema, parentNamespace string, namespaces Namespaces) string {
+	local := current.XMLName.Local
+	module := namespaces.Module(current.XMLName.Space)
+	if parentSchema != nil {
+		for _, candidate := range propertyNames(parentSchema) {
+			candidateModule, candidateLocal := splitName(candidate)
+			if candidateLocal == local && (candidateModule == "" || module == "" || candidateModule == module) {
+				return candidate
+			}
+		}
+	}
+	if current.XMLName.Space != parentNamespace && module != "" {
+		return module + ":" + local
+	}
+	return local
+}
+
+func propertyNames(schema *openapi3.Schema) []string {
+	schema = database.ItemSchema(schema)
+	var names []string
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		for _, ref := range refs {
+			if ref.Value != nil {
+				names = append(names, propertyNames(ref.Value)...)
+			}
+		}
+	}
+	return names
+}
+
+func elementValue(current *element, schema *openapi3.Schema, namespaces Namespaces) interface{} {
+	itemSchema := database.ItemSchema(schema)
+	if len(current.Children) > 0 || (itemSchema != nil && itemSchema.Type == "object") {
+		object := map[string]interface{}{}
+		for _, child := range current.Children {
+			addMember(object, child, itemSchema, current.XMLName.Space, namespaces)
+		}
+		return object
+	}
+	if itemSchema == nil {
+		return current.Text
+	}
+	switch itemSchema.Type {
+	case "integer", "number":
+		if number, err := strconv.ParseFloat(current.Text, 64); err == nil {
+			return number
+		}
+	case "boolean":
+		if boolean, err := strconv.ParseBool(current.Text); err == nil {
+			return boolean
+		}
+	}
+	return current.Text
+}
+
+func isList(schema *openapi3.Schema) bool {
+	return schema != nil && schema.Type == "array"
+}
@@ -0,0 +1,74 @@
+package yangxml
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/exgphe/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+)
+
+const jukeboxSchema = `{
+	"type": "object",
+	"properties": {
+		"example-jukebox:jukebox": {
+			"type": "object",
+			"properties": {
+				"artist": {
+					"type": "array",
+					"x-key": "name",
+					"items": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string"},
+							"album-count": {"type": "integer"},
+							"favorite": {"type": "boolean"}
+						}
+					}
+				},
+				"library": {"type": "object", "properties": {}}
+			}
+		}
+	}
+}`
+
+func TestUnmarshal_XMLData_JSONEncodingFollowingSchema(t *testing.T) {
+	var schema openapi3.Schema
+	_ = json.Unmarshal([]byte(jukeboxSchema), &schema)
+	xml := `<?xml version="1.0"?>
+<jukebox xmlns="http://example.com/ns/example-jukebox">
+	<artist><name>Foo Fighters</name><album-count>3</album-count><favorite>true</favorite></artist>
+	<library/>
+	<shuffle xmlns="urn:ietf:params:xml:ns:yang:example-augment">true</shuffle>
+</jukebox>`
+
+	data, err := Unmarshal([]byte(xml), &schema, Namespaces{"example-jukebox": "http://example.com/ns/example-jukebox"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"example-jukebox:jukebox": map[string]interface{}{
+			"artist": []interface{}{
+				map[string]interface{}{"name": "Foo Fighters", "album-count": float64(3), "favorite": true},
+			},
+			"library":                 map[string]interface{}{},
+			"example-augment:shuffle": "true",
+		},
+	}, data)
+}
+
+func TestUnmarshal_RepeatedUnknownElements_Array(t *testing.T) {
+	xml := `<tags xmlns="urn:ietf:params:xml:ns:yang:example"><tag>a</tag><tag>b</tag></tags>`
+
+	data, err := Unmarshal([]byte(xml), nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"example:tags": map[string]interface{}{"tag": []interface{}{"a", "b"}},
+	}, data)
+}
+
+func TestUnmarshal_MalformedXML_Error(t *testing.T) {
+	_, err := Unmarshal([]byte(`<tags><tag>`), nil, nil)
+
+	assert.Error(t, err)
+}